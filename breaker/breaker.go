@@ -0,0 +1,104 @@
+// Package breaker is a circuit breaker for calls to a dependency that may go
+// down. After a run of failures the circuit opens and callers skip the
+// dependency; once the cooldown has passed a single call is let through to
+// probe it, closing the circuit again when it succeeds.
+package breaker
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// State of a circuit
+type State int
+
+const (
+	Closed   State = iota // Calls go through
+	Open                  // Calls are skipped until the cooldown has passed
+	HalfOpen              // One probing call is in flight
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker tracks the failures of one dependency. It is safe for concurrent use.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // When the circuit last opened or started probing
+}
+
+// New creates a closed breaker that opens after threshold consecutive
+// failures and probes again after cooldown. name is only used in logs.
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{name: name, threshold: max(threshold, 1), cooldown: cooldown}
+}
+
+// State returns the current state of the circuit
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call should go through. A call that was allowed
+// should be followed by Record.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Closed:
+		return true
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		b.openedAt = time.Now()
+		log.Printf("Circuit %s half-open, probing", b.name)
+		return true
+	}
+	// Only the probing call goes through while half-open, unless it never
+	// reported back
+	if time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.openedAt = time.Now()
+	return true
+}
+
+// Record reports the outcome of an allowed call, nil meaning success
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.state != Closed {
+			log.Printf("Circuit %s closed", b.name)
+		}
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		if b.state != Open {
+			log.Printf("Circuit %s open after %d failures: %v", b.name, b.failures, err)
+		}
+		b.state = Open
+		b.openedAt = time.Now()
+		b.failures = 0
+	}
+}
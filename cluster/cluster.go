@@ -0,0 +1,183 @@
+// Package cluster aggregates points into map clusters for every zoom level.
+// Clusters come from a Web Mercator grid whose cells halve in size with
+// every zoom level, so each cluster is split exactly into the clusters one
+// level below it.
+package cluster
+
+import (
+	"go-server/rtree"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	// MaxZoom is the deepest precomputed zoom level, deeper queries get its clusters
+	MaxZoom = 18
+	// cellPixels is the width of a grid cell in 256 pixel tiles
+	cellPixels = 64
+	// maxLat is the latitude limit of Web Mercator
+	maxLat = 85.05112878
+)
+
+// Point is a clustered POI
+type Point struct {
+	ID   string
+	Lon  float64
+	Lat  float64
+	Type string
+}
+
+// Cluster is a group of points at one zoom level
+type Cluster struct {
+	Lon          float64 `json:"lon"` // Centroid of the points
+	Lat          float64 `json:"lat"`
+	Count        int     `json:"count"`
+	DominantType string  `json:"dominant_type"`
+	// ExpansionZoom is the first zoom level where the cluster splits up,
+	// MaxZoom + 1 when its points can't be told apart
+	ExpansionZoom int    `json:"expansion_zoom,omitempty"`
+	POIID         string `json:"poi_id,omitempty"` // Set on single point clusters
+}
+
+// Index holds the clusters of every zoom level. It is immutable.
+type Index struct {
+	levels [MaxZoom + 1]level
+}
+
+type level struct {
+	clusters []Cluster
+	tree     *rtree.Tree // Centroids, with the position in clusters as ID
+}
+
+// cell is a cluster under construction
+type cell struct {
+	x, y     int
+	sumLon   float64
+	sumLat   float64
+	count    int
+	types    map[string]int
+	children int
+	cluster  Cluster
+}
+
+// New clusters points for zoom levels 0 to MaxZoom
+func New(points []Point) *Index {
+	index := &Index{}
+
+	// The deepest level is built from the points
+	cells := map[[2]int]*cell{}
+	for _, point := range points {
+		x, y := gridCell(point.Lon, point.Lat, MaxZoom)
+		c := cells[[2]int{x, y}]
+		if c == nil {
+			c = &cell{x: x, y: y, types: map[string]int{}}
+			cells[[2]int{x, y}] = c
+		}
+		c.sumLon += point.Lon
+		c.sumLat += point.Lat
+		c.count++
+		c.types[point.Type]++
+		c.cluster.POIID = point.ID
+	}
+	for _, c := range cells {
+		if c.count > 1 {
+			c.cluster.POIID = ""
+			c.cluster.ExpansionZoom = MaxZoom + 1
+		}
+	}
+	index.levels[MaxZoom] = newLevel(cells)
+
+	// Every other level merges the four cells below each of its cells
+	for zoom := MaxZoom - 1; zoom >= 0; zoom-- {
+		parents := map[[2]int]*cell{}
+		for _, child := range cells {
+			key := [2]int{child.x >> 1, child.y >> 1}
+			c := parents[key]
+			if c == nil {
+				c = &cell{x: key[0], y: key[1], types: map[string]int{}}
+				parents[key] = c
+			}
+			c.sumLon += child.sumLon
+			c.sumLat += child.sumLat
+			c.count += child.count
+			for poiType, count := range child.types {
+				c.types[poiType] += count
+			}
+			c.children++
+			c.cluster = child.cluster
+		}
+		for _, c := range parents {
+			if c.children > 1 {
+				c.cluster = Cluster{ExpansionZoom: zoom + 1}
+			}
+		}
+		index.levels[zoom] = newLevel(parents)
+		cells = parents
+	}
+	return index
+}
+
+// newLevel finishes the cells of a level and indexes their centroids
+func newLevel(cells map[[2]int]*cell) level {
+	l := level{clusters: make([]Cluster, 0, len(cells))}
+	items := make([]rtree.Item, 0, len(cells))
+	for _, c := range cells {
+		cluster := c.cluster
+		cluster.Lon = c.sumLon / float64(c.count)
+		cluster.Lat = c.sumLat / float64(c.count)
+		cluster.Count = c.count
+		cluster.DominantType = dominantType(c.types)
+		items = append(items, rtree.Item{ID: strconv.Itoa(len(l.clusters)), X: cluster.Lon, Y: cluster.Lat})
+		l.clusters = append(l.clusters, cluster)
+	}
+	l.tree = rtree.New(items)
+	return l
+}
+
+// Within returns the clusters of a zoom level whose centroid lies inside a
+// lon/lat rectangle, largest first
+func (ix *Index) Within(minLon, minLat, maxLon, maxLat float64, zoom int) []Cluster {
+	l := ix.levels[min(max(zoom, 0), MaxZoom)]
+	clusters := []Cluster{}
+	l.tree.Search(rtree.Rect{MinX: minLon, MinY: minLat, MaxX: maxLon, MaxY: maxLat}, func(item rtree.Item) bool {
+		i, _ := strconv.Atoi(item.ID)
+		clusters = append(clusters, l.clusters[i])
+		return true
+	})
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		if clusters[i].Lon != clusters[j].Lon {
+			return clusters[i].Lon < clusters[j].Lon
+		}
+		return clusters[i].Lat < clusters[j].Lat
+	})
+	return clusters
+}
+
+// gridCell returns the grid cell of a point at a zoom level
+func gridCell(lon, lat float64, zoom int) (int, int) {
+	lat = math.Max(-maxLat, math.Min(maxLat, lat))
+	x := (lon + 180) / 360
+	sin := math.Sin(lat * math.Pi / 180)
+	y := 0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)
+	n := float64(int(1)<<zoom) * 256 / cellPixels
+	return clampCell(int(x*n), n), clampCell(int(y*n), n)
+}
+
+func clampCell(i int, n float64) int {
+	return min(max(i, 0), int(n)-1)
+}
+
+// dominantType is the most common type, ties going to the first by name
+func dominantType(types map[string]int) string {
+	best, bestCount := "", 0
+	for poiType, count := range types {
+		if count > bestCount || (count == bestCount && poiType < best) {
+			best, bestCount = poiType, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,57 @@
+package exporters
+
+import (
+	"encoding/csv"
+	"go-server/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVExporter writes the same columns the CSV importer reads, so exports can be re-imported
+type CSVExporter struct{}
+
+func init() {
+	Register("csv", CSVExporter{})
+}
+
+func (CSVExporter) ContentType() string { return "text/csv" }
+func (CSVExporter) Extension() string   { return "csv" }
+
+func (CSVExporter) NewWriter(w io.Writer) (Writer, error) {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "external_id", "name", "description", "type", "address", "tags", "opening_hours", "timezone", "lat", "lon"}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: writer}, nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WritePOI(poi models.POI) error {
+	lon, lat, ok := lonLat(poi)
+	if !ok {
+		return nil
+	}
+	return c.w.Write([]string{
+		poi.ID,
+		poi.ExternalID,
+		poi.Name,
+		poi.Description,
+		poi.Type,
+		poi.Address,
+		strings.Join(poi.Tags, ";"),
+		poi.OpeningHours,
+		poi.Timezone,
+		strconv.FormatFloat(lat, 'f', -1, 64),
+		strconv.FormatFloat(lon, 'f', -1, 64),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,57 @@
+package exporters
+
+import (
+	"fmt"
+	"go-server/models"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Writer streams POIs into an output format one at a time
+type Writer interface {
+	WritePOI(poi models.POI) error
+	// Close writes any trailing data, it does not close the underlying writer
+	Close() error
+}
+
+// Exporter creates streaming writers for an output format
+type Exporter interface {
+	ContentType() string
+	Extension() string
+	NewWriter(w io.Writer) (Writer, error)
+}
+
+var registry = map[string]Exporter{}
+
+// Register makes an exporter available under the given format name
+func Register(format string, exporter Exporter) {
+	registry[strings.ToLower(format)] = exporter
+}
+
+// Get returns the exporter registered for a format
+func Get(format string) (Exporter, error) {
+	exporter, ok := registry[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return exporter, nil
+}
+
+// Formats lists the registered format names
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for format := range registry {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// lonLat returns a POI's coordinates, or false when it has no usable location
+func lonLat(poi models.POI) (float64, float64, bool) {
+	if len(poi.Location.Coordinates) < 2 {
+		return 0, 0, false
+	}
+	return poi.Location.Coordinates[0], poi.Location.Coordinates[1], true
+}
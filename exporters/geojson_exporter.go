@@ -0,0 +1,67 @@
+package exporters
+
+import (
+	"encoding/json"
+	"go-server/models"
+	"io"
+)
+
+// GeoJSONExporter writes a FeatureCollection of Point features
+type GeoJSONExporter struct{}
+
+func init() {
+	Register("geojson", GeoJSONExporter{})
+}
+
+func (GeoJSONExporter) ContentType() string { return "application/geo+json" }
+func (GeoJSONExporter) Extension() string   { return "geojson" }
+
+func (GeoJSONExporter) NewWriter(w io.Writer) (Writer, error) {
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return nil, err
+	}
+	return &geoJSONWriter{w: w}, nil
+}
+
+type geoJSONWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (g *geoJSONWriter) WritePOI(poi models.POI) error {
+	if _, _, ok := lonLat(poi); !ok {
+		return nil
+	}
+	feature := map[string]any{
+		"type":     "Feature",
+		"id":       poi.ID,
+		"geometry": poi.Location,
+		"properties": map[string]any{
+			"external_id":   poi.ExternalID,
+			"name":          poi.Name,
+			"description":   poi.Description,
+			"type":          poi.Type,
+			"tags":          poi.Tags,
+			"address":       poi.Address,
+			"opening_hours": poi.OpeningHours,
+			"timezone":      poi.Timezone,
+		},
+	}
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return err
+	}
+	if g.count > 0 {
+		if _, err := io.WriteString(g.w, ","); err != nil {
+			return err
+		}
+	}
+	g.count++
+	_, err = g.w.Write(data)
+	return err
+}
+
+func (g *geoJSONWriter) Close() error {
+	_, err := io.WriteString(g.w, "]}\n")
+	return err
+}
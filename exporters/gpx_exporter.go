@@ -0,0 +1,47 @@
+package exporters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go-server/models"
+	"io"
+	"strconv"
+)
+
+// GPXExporter writes a GPX 1.1 file with one waypoint per POI
+type GPXExporter struct{}
+
+func init() {
+	Register("gpx", GPXExporter{})
+}
+
+func (GPXExporter) ContentType() string { return "application/gpx+xml" }
+func (GPXExporter) Extension() string   { return "gpx" }
+
+func (GPXExporter) NewWriter(w io.Writer) (Writer, error) {
+	_, err := io.WriteString(w, xml.Header+`<gpx version="1.1" creator="go-where" xmlns="http://www.topografix.com/GPX/1/1">`+"\n")
+	if err != nil {
+		return nil, err
+	}
+	return &gpxWriter{w: w}, nil
+}
+
+type gpxWriter struct {
+	w io.Writer
+}
+
+func (g *gpxWriter) WritePOI(poi models.POI) error {
+	lon, lat, ok := lonLat(poi)
+	if !ok {
+		return nil
+	}
+	_, err := fmt.Fprintf(g.w, "<wpt lat=\"%s\" lon=\"%s\"><name>%s</name><desc>%s</desc><type>%s</type></wpt>\n",
+		strconv.FormatFloat(lat, 'f', -1, 64), strconv.FormatFloat(lon, 'f', -1, 64),
+		escapeXML(poi.Name), escapeXML(poi.Description), escapeXML(poi.Type))
+	return err
+}
+
+func (g *gpxWriter) Close() error {
+	_, err := io.WriteString(g.w, "</gpx>\n")
+	return err
+}
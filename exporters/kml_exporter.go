@@ -0,0 +1,57 @@
+package exporters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go-server/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KMLExporter writes a KML 2.2 document with one Placemark per POI
+type KMLExporter struct{}
+
+func init() {
+	Register("kml", KMLExporter{})
+}
+
+func (KMLExporter) ContentType() string { return "application/vnd.google-earth.kml+xml" }
+func (KMLExporter) Extension() string   { return "kml" }
+
+func (KMLExporter) NewWriter(w io.Writer) (Writer, error) {
+	_, err := io.WriteString(w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>POIs</name>`+"\n")
+	if err != nil {
+		return nil, err
+	}
+	return &kmlWriter{w: w}, nil
+}
+
+type kmlWriter struct {
+	w io.Writer
+}
+
+func (k *kmlWriter) WritePOI(poi models.POI) error {
+	lon, lat, ok := lonLat(poi)
+	if !ok {
+		return nil
+	}
+	_, err := fmt.Fprintf(k.w, "<Placemark id=\"%s\"><name>%s</name><description>%s</description>"+
+		`<ExtendedData><Data name="type"><value>%s</value></Data><Data name="address"><value>%s</value></Data><Data name="tags"><value>%s</value></Data></ExtendedData>`+
+		"<Point><coordinates>%s,%s</coordinates></Point></Placemark>\n",
+		escapeXML(poi.ID), escapeXML(poi.Name), escapeXML(poi.Description),
+		escapeXML(poi.Type), escapeXML(poi.Address), escapeXML(strings.Join(poi.Tags, ";")),
+		strconv.FormatFloat(lon, 'f', -1, 64), strconv.FormatFloat(lat, 'f', -1, 64))
+	return err
+}
+
+func (k *kmlWriter) Close() error {
+	_, err := io.WriteString(k.w, "</Document></kml>\n")
+	return err
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
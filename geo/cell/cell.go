@@ -0,0 +1,34 @@
+// Package cell indexes positions by grid cell, for grouping and caching by
+// area rather than by raw coordinates. It has two grids: geohashes, and a
+// hierarchical hexagonal grid in the spirit of H3.
+package cell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cell is an area of one of the grids
+type Cell interface {
+	// ID identifies the cell, see Parse
+	ID() string
+	// Center returns the position at the middle of the cell
+	Center() (lon, lat float64)
+	// Bounds returns a lon/lat rectangle around the cell
+	Bounds() (minLon, minLat, maxLon, maxLat float64)
+	// Contains reports whether a position falls in the cell
+	Contains(lon, lat float64) bool
+}
+
+// Parse reads a cell ID: a geohash such as "w21z7", or a hex cell such as
+// "hex:15:26430:-1537"
+func Parse(id string) (Cell, error) {
+	if strings.HasPrefix(id, hexPrefix) {
+		return ParseHex(id)
+	}
+	hash, err := ParseGeohash(id)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a geohash nor a hex cell", id)
+	}
+	return hash, nil
+}
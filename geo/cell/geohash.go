@@ -0,0 +1,147 @@
+package cell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxGeohashPrecision is the longest geohash, about 4cm by 2cm
+const MaxGeohashPrecision = 12
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash is a cell of the geohash grid. Every extra character splits a
+// cell into 32, and cells sharing a prefix are inside the shorter cell.
+type Geohash string
+
+// EncodeGeohash returns the geohash of a position with precision characters
+func EncodeGeohash(lon, lat float64, precision int) Geohash {
+	precision = min(max(precision, 1), MaxGeohashPrecision)
+	minLon, maxLon := -180.0, 180.0
+	minLat, maxLat := -90.0, 90.0
+	hash := make([]byte, 0, precision)
+	bits, value := 0, 0
+	evenBit := true // Bits alternate between longitude and latitude, longitude first
+	for len(hash) < precision {
+		if evenBit {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				value = value<<1 | 1
+				minLon = mid
+			} else {
+				value <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				value = value<<1 | 1
+				minLat = mid
+			} else {
+				value <<= 1
+				maxLat = mid
+			}
+		}
+		evenBit = !evenBit
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[value])
+			bits, value = 0, 0
+		}
+	}
+	return Geohash(hash)
+}
+
+// ParseGeohash checks a geohash, accepting upper case
+func ParseGeohash(s string) (Geohash, error) {
+	s = strings.ToLower(s)
+	if s == "" || len(s) > MaxGeohashPrecision {
+		return "", fmt.Errorf("geohash must have 1 to %d characters", MaxGeohashPrecision)
+	}
+	for _, r := range s {
+		if !strings.ContainsRune(geohashAlphabet, r) {
+			return "", fmt.Errorf("invalid geohash character %q", r)
+		}
+	}
+	return Geohash(s), nil
+}
+
+func (g Geohash) ID() string {
+	return string(g)
+}
+
+// Precision is the number of characters
+func (g Geohash) Precision() int {
+	return len(g)
+}
+
+func (g Geohash) Bounds() (minLon, minLat, maxLon, maxLat float64) {
+	minLon, maxLon = -180.0, 180.0
+	minLat, maxLat = -90.0, 90.0
+	evenBit := true
+	for i := 0; i < len(g); i++ {
+		value := strings.IndexByte(geohashAlphabet, g[i])
+		for bit := 4; bit >= 0; bit-- {
+			set := value>>bit&1 == 1
+			if evenBit {
+				mid := (minLon + maxLon) / 2
+				if set {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if set {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+	return minLon, minLat, maxLon, maxLat
+}
+
+// Center is also the decoded position of the geohash, within half a cell
+func (g Geohash) Center() (lon, lat float64) {
+	minLon, minLat, maxLon, maxLat := g.Bounds()
+	return (minLon + maxLon) / 2, (minLat + maxLat) / 2
+}
+
+func (g Geohash) Contains(lon, lat float64) bool {
+	return strings.HasPrefix(string(EncodeGeohash(lon, lat, len(g))), string(g))
+}
+
+// Parent returns the cell one character shorter, the cell itself at precision 1
+func (g Geohash) Parent() Geohash {
+	if len(g) <= 1 {
+		return g
+	}
+	return g[:len(g)-1]
+}
+
+// Neighbours returns the adjacent cells of the same precision, clockwise
+// from north. Longitudes wrap around the antimeridian; cells touching a pole
+// have no neighbours past it, so fewer than 8 may be returned.
+func (g Geohash) Neighbours() []Geohash {
+	minLon, minLat, maxLon, maxLat := g.Bounds()
+	lon, lat := (minLon+maxLon)/2, (minLat+maxLat)/2
+	width, height := maxLon-minLon, maxLat-minLat
+	offsets := [8][2]float64{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}}
+	neighbours := make([]Geohash, 0, len(offsets))
+	for _, offset := range offsets {
+		neighbourLat := lat + offset[1]*height
+		if neighbourLat < -90 || neighbourLat > 90 {
+			continue
+		}
+		neighbourLon := lon + offset[0]*width
+		if neighbourLon > 180 {
+			neighbourLon -= 360
+		} else if neighbourLon < -180 {
+			neighbourLon += 360
+		}
+		neighbours = append(neighbours, EncodeGeohash(neighbourLon, neighbourLat, len(g)))
+	}
+	return neighbours
+}
@@ -0,0 +1,156 @@
+package cell
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxHexResolution is the finest hex grid, with cells about 5m across at the
+// equator. Cells at resolution 0 are about 5000km across, and each
+// resolution halves the size.
+const MaxHexResolution = 20
+
+const (
+	hexPrefix = "hex:"
+	// mercatorMaxLat is the latitude limit of Web Mercator
+	mercatorMaxLat = 85.05112878
+)
+
+// Hex is a cell of a pointy-top hexagonal grid laid over Web Mercator, in
+// axial coordinates. Unlike geohashes, the cells of one resolution don't
+// nest exactly inside the cells of the coarser one.
+type Hex struct {
+	Res int
+	Q   int
+	R   int
+}
+
+// hexSize is the center to corner distance of cells, in Web Mercator units
+// where the world is 1 wide
+func hexSize(res int) float64 {
+	return 1 / float64(int(8)<<res)
+}
+
+// HexAt returns the cell of a position at a resolution
+func HexAt(lon, lat float64, res int) Hex {
+	res = min(max(res, 0), MaxHexResolution)
+	x, y := mercator(lon, lat)
+	size := hexSize(res)
+	q := (math.Sqrt(3)/3*x - y/3) / size
+	r := 2 * y / 3 / size
+	return roundHex(res, q, r)
+}
+
+// ParseHex reads a cell ID of the form hex:<res>:<q>:<r>
+func ParseHex(id string) (Hex, error) {
+	parts := strings.Split(strings.TrimPrefix(id, hexPrefix), ":")
+	if !strings.HasPrefix(id, hexPrefix) || len(parts) != 3 {
+		return Hex{}, fmt.Errorf("hex cell IDs look like hex:<res>:<q>:<r>")
+	}
+	var values [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return Hex{}, fmt.Errorf("invalid hex cell coordinate %q", part)
+		}
+		values[i] = v
+	}
+	h := Hex{Res: values[0], Q: values[1], R: values[2]}
+	if h.Res < 0 || h.Res > MaxHexResolution {
+		return Hex{}, fmt.Errorf("hex resolution must be 0 to %d", MaxHexResolution)
+	}
+	return h, nil
+}
+
+func (h Hex) ID() string {
+	return fmt.Sprintf("%s%d:%d:%d", hexPrefix, h.Res, h.Q, h.R)
+}
+
+// Center clamps to the world, so cells crossing the antimeridian get a
+// position on their edge that still lies in the cell
+func (h Hex) Center() (lon, lat float64) {
+	size := hexSize(h.Res)
+	x := size * math.Sqrt(3) * (float64(h.Q) + float64(h.R)/2)
+	y := size * 1.5 * float64(h.R)
+	lon, lat = unmercator(x, y)
+	return math.Max(-180, math.Min(180, lon)), lat
+}
+
+// Boundary returns the six corners of the cell as lon/lat pairs, counter
+// clockwise from the east-north-east corner. Corners of cells crossing the
+// antimeridian have longitudes past 180 or -180.
+func (h Hex) Boundary() [6][2]float64 {
+	size := hexSize(h.Res)
+	cx := size * math.Sqrt(3) * (float64(h.Q) + float64(h.R)/2)
+	cy := size * 1.5 * float64(h.R)
+	var corners [6][2]float64
+	for i := range corners {
+		angle := math.Pi / 180 * float64(60*i+30)
+		lon, lat := unmercator(cx+size*math.Cos(angle), cy+size*math.Sin(angle))
+		corners[i] = [2]float64{lon, lat}
+	}
+	return corners
+}
+
+// Bounds clamps to the world, so cells crossing the antimeridian only cover
+// their part on this side of it
+func (h Hex) Bounds() (minLon, minLat, maxLon, maxLat float64) {
+	size := hexSize(h.Res)
+	cx := size * math.Sqrt(3) * (float64(h.Q) + float64(h.R)/2)
+	cy := size * 1.5 * float64(h.R)
+	halfWidth := size * math.Sqrt(3) / 2
+	minLon, minLat = unmercator(cx-halfWidth, cy-size)
+	maxLon, maxLat = unmercator(cx+halfWidth, cy+size)
+	return math.Max(minLon, -180), minLat, math.Min(maxLon, 180), maxLat
+}
+
+func (h Hex) Contains(lon, lat float64) bool {
+	return HexAt(lon, lat, h.Res) == h
+}
+
+// Parent returns the cell one resolution coarser holding this cell's center
+func (h Hex) Parent() Hex {
+	if h.Res == 0 {
+		return h
+	}
+	lon, lat := h.Center()
+	return HexAt(lon, lat, h.Res-1)
+}
+
+// Neighbours returns the six adjacent cells
+func (h Hex) Neighbours() []Hex {
+	directions := [6][2]int{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+	neighbours := make([]Hex, 0, len(directions))
+	for _, d := range directions {
+		neighbours = append(neighbours, Hex{Res: h.Res, Q: h.Q + d[0], R: h.R + d[1]})
+	}
+	return neighbours
+}
+
+// roundHex rounds fractional axial coordinates to the nearest cell
+func roundHex(res int, q, r float64) Hex {
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return Hex{Res: res, Q: int(rq), R: int(rr)}
+}
+
+// mercator projects a position to Web Mercator with the world 1 wide,
+// centered on 0,0 and north up
+func mercator(lon, lat float64) (x, y float64) {
+	lat = math.Max(-mercatorMaxLat, math.Min(mercatorMaxLat, lat))
+	sin := math.Sin(lat * math.Pi / 180)
+	return lon / 360, math.Log((1+sin)/(1-sin)) / (4 * math.Pi)
+}
+
+// unmercator is the inverse of mercator, without wrapping longitudes
+func unmercator(x, y float64) (lon, lat float64) {
+	return x * 360, math.Atan(math.Sinh(2*math.Pi*y)) * 180 / math.Pi
+}
@@ -0,0 +1,148 @@
+package geocode
+
+import (
+	"go-server/search"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// streetSuffixes maps the last words of street names, full and abbreviated,
+// to their full form
+var streetSuffixes = map[string]string{
+	"road": "road", "rd": "road", "street": "street", "st": "street",
+	"avenue": "avenue", "ave": "avenue", "av": "avenue", "drive": "drive", "dr": "drive",
+	"crescent": "crescent", "cres": "crescent", "place": "place", "pl": "place",
+	"lane": "lane", "ln": "lane", "quay": "quay", "gate": "gate", "walk": "walk",
+	"way": "way", "close": "close", "cl": "close", "boulevard": "boulevard", "blvd": "boulevard",
+	"link": "link", "rise": "rise", "terrace": "terrace", "ter": "terrace", "view": "view",
+	"hill": "hill", "square": "square", "sq": "square",
+}
+
+// streetPrefixes are the first words of street names like "Jalan Besar"
+var streetPrefixes = map[string]bool{"jalan": true, "jln": true, "lorong": true, "lor": true}
+
+// abbreviations are expanded wherever they appear in a street name.
+// "st" is not among them: it means saint before the last word and street
+// as the last word.
+var abbreviations = map[string]string{
+	"jln": "jalan", "lor": "lorong", "upp": "upper", "bt": "bukit", "kg": "kampong",
+	"tg": "tanjong", "mt": "mount", "nth": "north", "sth": "south", "ctr": "centre",
+	"center": "centre", "blk": "block",
+}
+
+var (
+	// Singapore postal codes are six digits, often after "Singapore" or "S"
+	postalCodePattern = regexp.MustCompile(`(?i)\b(?:singapore|s)?\s*\(?(\d{6})\b\)?`)
+	// Unit numbers look like "#01-23" or "#B1-05A"
+	unitPattern = regexp.MustCompile(`(?i)(?:#|\bunit\s+)\s*([a-z]?\d+-\d+[a-z]?)`)
+)
+
+// Address is an address split into normalized parts
+type Address struct {
+	HouseNumber string   // e.g. "1", "825b" or "24/26", blocks included
+	Unit        string   // e.g. "01-23" from "#01-23"
+	Street      []string // Normalized street words, e.g. saint andrew road
+	PostalCode  string   // Six digits
+	Other       []string // Normalized words outside the street, like building names
+}
+
+// ParseAddress splits an address or a free text query into its parts.
+// Abbreviations are expanded, so "1 St Andrew's Rd" and "1 Saint Andrews
+// Road" parse the same.
+func ParseAddress(s string) Address {
+	var address Address
+	s, address.Unit, address.PostalCode = stripCodes(s)
+	for _, part := range strings.Split(s, ",") {
+		houseNumber, street, ok := streetPart(part)
+		if ok && address.Street == nil {
+			address.HouseNumber = strings.ToLower(houseNumber)
+			address.Street = normalizeStreet(street)
+			continue
+		}
+		address.Other = append(address.Other, normalizeWords(search.Tokenize(part))...)
+	}
+	return address
+}
+
+// Street returns the street of an address without house or unit numbers,
+// e.g. "St Andrew's Road" for "1 St Andrew's Road", or "" when no part of
+// the address looks like a street
+func Street(address string) string {
+	address, _, _ = stripCodes(address)
+	for _, part := range strings.Split(address, ",") {
+		if _, street, ok := streetPart(part); ok {
+			return strings.Join(street, " ")
+		}
+	}
+	return ""
+}
+
+// stripCodes takes the unit number and postal code out of an address
+func stripCodes(s string) (rest, unit, postalCode string) {
+	if match := unitPattern.FindStringSubmatch(s); match != nil {
+		unit = strings.ToLower(match[1])
+		s = strings.Replace(s, match[0], " ", 1)
+	}
+	if match := postalCodePattern.FindStringSubmatch(s); match != nil {
+		postalCode = match[1]
+		s = strings.Replace(s, match[0], " ", 1)
+	}
+	return s, unit, postalCode
+}
+
+// streetPart recognizes one comma separated part of an address as a street,
+// with or without a house number
+func streetPart(part string) (houseNumber string, street []string, ok bool) {
+	words := strings.Fields(part)
+	if len(words) > 2 && isBlock(words[0]) && startsWithDigit(words[1]) {
+		return words[1], words[2:], true
+	}
+	if len(words) > 1 && startsWithDigit(words[0]) {
+		// A house number, e.g. "1", "825B", "31-K" or "24/26"
+		return words[0], words[1:], true
+	}
+	if len(words) > 1 {
+		first, last := search.Normalize(words[0]), search.Normalize(words[len(words)-1])
+		if streetSuffixes[last] != "" || streetPrefixes[first] {
+			return "", words, true
+		}
+	}
+	return "", nil, false
+}
+
+// normalizeStreet tokenizes a street name and expands its abbreviations
+func normalizeStreet(words []string) []string {
+	tokens := search.Tokenize(strings.Join(words, " "))
+	for i, token := range tokens {
+		switch {
+		case i == len(tokens)-1 && streetSuffixes[token] != "":
+			tokens[i] = streetSuffixes[token]
+		case token == "st":
+			tokens[i] = "saint"
+		}
+	}
+	return normalizeWords(tokens)
+}
+
+// normalizeWords expands abbreviations in tokens, in place
+func normalizeWords(tokens []string) []string {
+	for i, token := range tokens {
+		if full, ok := abbreviations[token]; ok {
+			tokens[i] = full
+		}
+	}
+	return tokens
+}
+
+func isBlock(word string) bool {
+	word = strings.ToLower(strings.TrimSuffix(word, "."))
+	return word == "blk" || word == "block"
+}
+
+func startsWithDigit(word string) bool {
+	for _, r := range word {
+		return unicode.IsDigit(r)
+	}
+	return false
+}
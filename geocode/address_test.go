@@ -0,0 +1,46 @@
+package geocode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Address
+	}{
+		{"1 St Andrew's Road", Address{HouseNumber: "1", Street: []string{"saint", "andrew", "road"}}},
+		{"1 Saint Andrews Rd", Address{HouseNumber: "1", Street: []string{"saint", "andrews", "road"}}},
+		{"60 Hill Street", Address{HouseNumber: "60", Street: []string{"hill", "street"}}},
+		{"Hill St", Address{Street: []string{"hill", "street"}}},
+		{"Blk 825B Tampines Avenue 4", Address{HouseNumber: "825b", Street: []string{"tampines", "avenue", "4"}}},
+		{"Jln Besar", Address{Street: []string{"jalan", "besar"}}},
+		{
+			"1 Beach Road, #01-23, Singapore 189673",
+			Address{HouseNumber: "1", Unit: "01-23", Street: []string{"beach", "road"}, PostalCode: "189673"},
+		},
+		{"Esplanade Park", Address{Other: []string{"esplanade", "park"}}},
+	}
+	for _, tt := range tests {
+		got := ParseAddress(tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAddress(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStreet(t *testing.T) {
+	tests := map[string]string{
+		"1 St Andrew's Road":                       "St Andrew's Road",
+		"60 Hill Street":                           "Hill Street",
+		"Esplanade Park, 1 Connaught Drive":        "Connaught Drive",
+		"Esplanade Park":                           "",
+		"#02-01, 3 Temasek Blvd, Singapore 038983": "Temasek Blvd",
+	}
+	for in, want := range tests {
+		if got := Street(in); got != want {
+			t.Errorf("Street(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
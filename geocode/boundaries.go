@@ -0,0 +1,92 @@
+// Package geocode resolves positions to places and back from local data,
+// without calling outside services.
+package geocode
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-server/geometry"
+)
+
+// nameProperties are the feature properties read as an area's name, in
+// order. PLN_AREA_N is used by the URA Master Plan planning area dataset.
+var nameProperties = []string{"name", "NAME", "PLN_AREA_N"}
+
+// Area is a named administrative area
+type Area struct {
+	Name  string
+	Kind  string // e.g. "region" or "planning_area", from the kind property
+	Shape geometry.MultiPolygon
+
+	bounds geometry.Bounds
+}
+
+// Boundaries is an immutable set of areas
+type Boundaries struct {
+	areas []Area
+}
+
+// LoadBoundaries reads areas from a GeoJSON FeatureCollection of Polygon and
+// MultiPolygon features
+func LoadBoundaries(data []byte) (*Boundaries, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Properties map[string]any  `json:"properties"`
+			Geometry   json.RawMessage `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected a FeatureCollection, got %q", collection.Type)
+	}
+
+	b := &Boundaries{}
+	for i, feature := range collection.Features {
+		shape, err := geometry.ParseArea(feature.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		area := Area{Shape: shape, bounds: shape.Bounds()}
+		for _, property := range nameProperties {
+			if name, ok := feature.Properties[property].(string); ok && name != "" {
+				area.Name = name
+				break
+			}
+		}
+		if area.Name == "" {
+			return nil, fmt.Errorf("feature %d has no name property", i)
+		}
+		area.Kind, _ = feature.Properties["kind"].(string)
+		b.areas = append(b.areas, area)
+	}
+	return b, nil
+}
+
+// Len returns the number of areas
+func (b *Boundaries) Len() int {
+	return len(b.areas)
+}
+
+// Locate returns the area containing a position, the one with the smallest
+// extent when areas are nested, or nil
+func (b *Boundaries) Locate(lon, lat float64) *Area {
+	var found *Area
+	foundSize := 0.0
+	for i := range b.areas {
+		area := &b.areas[i]
+		if lon < area.bounds.MinLon || lon > area.bounds.MaxLon || lat < area.bounds.MinLat || lat > area.bounds.MaxLat {
+			continue
+		}
+		if !area.Shape.Contains(lon, lat) {
+			continue
+		}
+		size := (area.bounds.MaxLon - area.bounds.MinLon) * (area.bounds.MaxLat - area.bounds.MinLat)
+		if found == nil || size < foundSize {
+			found, foundSize = area, size
+		}
+	}
+	return found
+}
@@ -0,0 +1,331 @@
+package geocode
+
+import (
+	"go-server/models"
+	"go-server/search"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Result kinds
+const (
+	KindAddress = "address" // A house number or postal code matched
+	KindStreet  = "street"  // A street matched, located at the middle of its POIs
+	KindPlace   = "place"   // A POI name matched
+)
+
+// MinConfidence is the lowest confidence of returned results
+const MinConfidence = 0.3
+
+// Weights of partial matches
+const (
+	streetOnlyFactor    = 0.8 // The query names a street without a house number
+	wrongNumberFactor   = 0.7 // The query's house number isn't known on the street
+	placeQueryCoverage  = 0.7 // Share of a place score for covering the query
+	placeNameCoverage   = 0.3 // Share for covering the POI's name
+	missingSuffixFactor = 0.9 // The query leaves out "Road", "Street", ...
+	otherSuffixFactor   = 0.8 // The query has "Road" where the street is a "Street"
+)
+
+// postalCodeKeyPrefix keeps postal codes apart from words in the postings
+const postalCodeKeyPrefix = "postal:"
+
+// Result is a geocoded location
+type Result struct {
+	Kind       string          `json:"kind"`
+	Label      string          `json:"label"`
+	Location   models.GeoPoint `json:"location"`
+	Confidence float64         `json:"confidence"` // 0 to 1
+	POIID      string          `json:"poi_id,omitempty"`
+}
+
+// Index resolves free text to POI addresses, streets and names. It is safe
+// for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	entries  map[string]entry
+	postings map[string]map[string]bool // Token -> POI IDs
+}
+
+type entry struct {
+	id       string
+	name     string
+	address  string
+	lon, lat float64
+	parsed   Address
+	words    []string // Normalized name words
+	keys     []string // Posting keys, for removal
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{entries: map[string]entry{}, postings: map[string]map[string]bool{}}
+}
+
+// Replace rebuilds the index from scratch
+func (idx *Index) Replace(pois []models.POI) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries = make(map[string]entry, len(pois))
+	idx.postings = map[string]map[string]bool{}
+	for _, poi := range pois {
+		idx.add(poi)
+	}
+}
+
+// Add indexes a POI, replacing any previous version of it
+func (idx *Index) Add(poi models.POI) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(poi.ID)
+	idx.add(poi)
+}
+
+// Remove drops a POI from the index
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) add(poi models.POI) {
+	if len(poi.Location.Coordinates) != 2 {
+		return
+	}
+	e := entry{
+		id:      poi.ID,
+		name:    poi.Name,
+		address: poi.Address,
+		lon:     poi.Location.Coordinates[0],
+		lat:     poi.Location.Coordinates[1],
+		parsed:  ParseAddress(poi.Address),
+		words:   normalizeWords(search.Tokenize(poi.Name)),
+	}
+	keys := map[string]bool{}
+	for _, word := range append(append(e.words, e.parsed.Street...), e.parsed.Other...) {
+		keys[singular(word)] = true
+	}
+	if e.parsed.PostalCode != "" {
+		keys[postalCodeKeyPrefix+e.parsed.PostalCode] = true
+	}
+	for key := range keys {
+		if idx.postings[key] == nil {
+			idx.postings[key] = map[string]bool{}
+		}
+		idx.postings[key][e.id] = true
+		e.keys = append(e.keys, key)
+	}
+	idx.entries[e.id] = e
+}
+
+func (idx *Index) remove(id string) {
+	for _, key := range idx.entries[id].keys {
+		delete(idx.postings[key], id)
+		if len(idx.postings[key]) == 0 {
+			delete(idx.postings, key)
+		}
+	}
+	delete(idx.entries, id)
+}
+
+// Geocode resolves a query such as "1 St Andrew's Rd", "Singapore 178957"
+// or "national gallery" to up to limit results, most confident first
+func (idx *Index) Geocode(query string, limit int) []Result {
+	q := ParseAddress(query)
+	words := append(append([]string{}, q.Street...), q.Other...)
+	generic := append(genericWords(q.Street), genericWords(q.Other)...)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	candidates := map[string]bool{}
+	for _, word := range words {
+		for id := range idx.postings[singular(word)] {
+			candidates[id] = true
+		}
+	}
+	if q.PostalCode != "" {
+		for id := range idx.postings[postalCodeKeyPrefix+q.PostalCode] {
+			candidates[id] = true
+		}
+	}
+
+	type street struct {
+		label          string
+		sumLon, sumLat float64
+		count          int
+		confidence     float64
+	}
+	streets := map[string]*street{}
+	var results []Result
+	for id := range candidates {
+		e := idx.entries[id]
+		best := Result{Location: models.GeoPoint{Type: "Point", Coordinates: []float64{e.lon, e.lat}}, POIID: e.id}
+
+		if q.PostalCode != "" && q.PostalCode == e.parsed.PostalCode {
+			best.Kind, best.Label, best.Confidence = KindAddress, e.address, 1
+		}
+		if overlap := streetOverlap(q.Street, e.parsed.Street); overlap > 0 {
+			if sameHouseNumber(q.HouseNumber, e.parsed.HouseNumber) && overlap > best.Confidence {
+				best.Kind, best.Label, best.Confidence = KindAddress, e.address, overlap
+			}
+			// Every POI on the street shares its distinctive words, so all
+			// of them are candidates and the street sits in their middle
+			factor := streetOnlyFactor
+			if q.HouseNumber != "" {
+				factor = wrongNumberFactor
+			}
+			key := strings.Join(e.parsed.Street, " ")
+			s := streets[key]
+			if s == nil {
+				s = &street{label: Street(e.address)}
+				streets[key] = s
+			}
+			s.sumLon += e.lon
+			s.sumLat += e.lat
+			s.count++
+			s.confidence = max(s.confidence, overlap*factor)
+		}
+		if confidence := placeConfidence(words, generic, e.words); confidence > best.Confidence {
+			best.Kind, best.Confidence = KindPlace, confidence
+			best.Label = e.name
+			if e.address != "" {
+				best.Label += ", " + e.address
+			}
+		}
+		// Addresses like "Esplanade Park" name a place rather than a street
+		if confidence := placeConfidence(words, generic, e.parsed.Other); confidence > best.Confidence {
+			best.Kind, best.Label, best.Confidence = KindPlace, e.name+", "+e.address, confidence
+		}
+		if best.Confidence >= MinConfidence {
+			results = append(results, best)
+		}
+	}
+	for _, s := range streets {
+		if s.confidence >= MinConfidence {
+			results = append(results, Result{
+				Kind:       KindStreet,
+				Label:      s.label,
+				Location:   models.GeoPoint{Type: "Point", Coordinates: []float64{s.sumLon / float64(s.count), s.sumLat / float64(s.count)}},
+				Confidence: s.confidence,
+			})
+		}
+	}
+
+	for i := range results {
+		results[i].Confidence = math.Round(results[i].Confidence*100) / 100
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Confidence != results[j].Confidence {
+			return results[i].Confidence > results[j].Confidence
+		}
+		if results[i].Label != results[j].Label {
+			return results[i].Label < results[j].Label
+		}
+		return results[i].POIID < results[j].POIID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// placeConfidence scores how well query words match a place name, mostly by
+// how much of the query the name covers. generic marks the query words that
+// say little on their own.
+func placeConfidence(query []string, generic []bool, name []string) float64 {
+	matched, distinctive := matchedWords(query, generic, name)
+	if distinctive == 0 {
+		return 0
+	}
+	return placeQueryCoverage*float64(matched)/float64(len(query)) +
+		placeNameCoverage*float64(matched)/float64(len(name))
+}
+
+// streetOverlap scores how well two street names match, 1 when they are the
+// same. Only the distinctive words count, so "Beach Road" doesn't match "St
+// Andrew's Road", and a missing or different suffix costs a little.
+func streetOverlap(query, street []string) float64 {
+	queryCore, querySuffix := splitSuffix(query)
+	streetCore, streetSuffix := splitSuffix(street)
+	if len(queryCore) == 0 || len(streetCore) == 0 {
+		return 0
+	}
+	// The suffix is split off, so only words like "saint" are generic in
+	// the core and "Hill Street" keeps "hill" as its distinctive word
+	matched, distinctive := matchedWords(queryCore, genericWords(query)[:len(queryCore)], streetCore)
+	if distinctive == 0 {
+		return 0
+	}
+	overlap := float64(matched) / float64(max(len(queryCore), len(streetCore)))
+	switch {
+	case querySuffix == streetSuffix:
+	case querySuffix == "":
+		overlap *= missingSuffixFactor
+	default:
+		overlap *= otherSuffixFactor
+	}
+	return overlap
+}
+
+// sameHouseNumber reports whether a queried house number is the address's,
+// or one of the numbers of a shared address like "24/26" or "180-226"
+func sameHouseNumber(query, houseNumber string) bool {
+	if query == "" {
+		return false
+	}
+	for _, number := range strings.FieldsFunc(houseNumber, func(r rune) bool { return r == '/' || r == '-' }) {
+		if number == query {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSuffix splits a normalized street into its name and suffix, if any
+func splitSuffix(street []string) (name []string, suffix string) {
+	if len(street) > 1 && streetSuffixes[street[len(street)-1]] != "" {
+		return street[:len(street)-1], street[len(street)-1]
+	}
+	return street, ""
+}
+
+// matchedWords counts the words of query found in words, and how many of
+// those aren't marked generic
+func matchedWords(query []string, generic []bool, words []string) (matched, distinctive int) {
+	for i, q := range query {
+		for _, word := range words {
+			if singular(q) == singular(word) {
+				matched++
+				if !generic[i] {
+					distinctive++
+				}
+				break
+			}
+		}
+	}
+	return matched, distinctive
+}
+
+// genericWords marks the normalized words that say little on their own:
+// "saint", "singapore" and street suffixes in the suffix position. "Hill"
+// is generic in "Bukit Timah Hill" but not in "Hill Street" or on its own.
+func genericWords(words []string) []bool {
+	generic := make([]bool, len(words))
+	for i, word := range words {
+		suffix := i > 0 && i == len(words)-1 && streetSuffixes[word] != ""
+		generic[i] = suffix || word == "saint" || word == "singapore"
+	}
+	return generic
+}
+
+// singular drops a plural or possessive "s" the tokenizer left, so
+// "andrews" matches "andrew"
+func singular(word string) string {
+	if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+		return word[:len(word)-1]
+	}
+	return word
+}
@@ -0,0 +1,107 @@
+package geocode
+
+import (
+	"go-server/models"
+	"testing"
+)
+
+func testIndex() *Index {
+	poi := func(id, name, address string, lon, lat float64) models.POI {
+		return models.POI{
+			ID:       id,
+			Name:     name,
+			Address:  address,
+			Location: models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}},
+		}
+	}
+	idx := NewIndex()
+	idx.Replace([]models.POI{
+		poi("gallery", "National Gallery Singapore", "1 St Andrew's Road", 103.8514, 1.2900),
+		poi("cathedral", "St Andrew's Cathedral", "11 St Andrew's Road", 103.8523, 1.2927),
+		poi("mica", "MICA Building", "140 Hill Street", 103.8490, 1.2906),
+		poi("sccci", "Singapore Chinese Chamber of Commerce", "60 Hill Street", 103.8508, 1.2912),
+		poi("raffles", "Raffles Hotel", "1 Beach Road", 103.8545, 1.2949),
+		poi("esplanade", "Esplanade Park", "Esplanade Park, Connaught Drive", 103.8535, 1.2895),
+		poi("bukit", "Bukit Timah Nature Reserve", "177 Hindhede Drive", 103.7763, 1.3546),
+	})
+	return idx
+}
+
+func TestGeocode(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantKind string
+		wantPOI  string // Empty for streets
+		wantConf float64
+	}{
+		{"1 St Andrew's Road", KindAddress, "gallery", 1},
+		{"1 Saint Andrews Rd", KindAddress, "gallery", 1},
+		{"60 Hill Street", KindAddress, "sccci", 1},
+		{"60 Hill St", KindAddress, "sccci", 1},
+		{"Hill Street", KindStreet, "", 0.8},
+		{"1 Beach Road", KindAddress, "raffles", 1},
+		{"national gallery", KindPlace, "gallery", 0.9},
+		{"raffles hotel", KindPlace, "raffles", 1},
+		{"esplanade park", KindPlace, "esplanade", 1},
+	}
+	idx := testIndex()
+	for _, tt := range tests {
+		results := idx.Geocode(tt.query, 5)
+		if len(results) == 0 {
+			t.Errorf("Geocode(%q) returned no results", tt.query)
+			continue
+		}
+		got := results[0]
+		if got.Kind != tt.wantKind || got.POIID != tt.wantPOI || got.Confidence != tt.wantConf {
+			t.Errorf("Geocode(%q)[0] = %s %q %v, want %s %q %v",
+				tt.query, got.Kind, got.POIID, got.Confidence, tt.wantKind, tt.wantPOI, tt.wantConf)
+		}
+	}
+}
+
+func TestGeocodeStreetLocation(t *testing.T) {
+	results := testIndex().Geocode("Hill Street", 5)
+	for _, r := range results {
+		if r.Kind != KindStreet {
+			continue
+		}
+		if r.Label != "Hill Street" {
+			t.Errorf("street label = %q, want %q", r.Label, "Hill Street")
+		}
+		// The middle of the two POIs on Hill Street
+		lon, lat := r.Location.Coordinates[0], r.Location.Coordinates[1]
+		if lon != (103.8490+103.8508)/2 || lat != (1.2906+1.2912)/2 {
+			t.Errorf("street location = %v, %v", lon, lat)
+		}
+		return
+	}
+	t.Errorf("Geocode(%q) = %+v, want a street", "Hill Street", results)
+}
+
+func TestGeocodeGenericWords(t *testing.T) {
+	idx := testIndex()
+	// Suffixes and "saint" alone don't pick out a street
+	for _, query := range []string{"Road", "99 Road", "Saint Road", "Singapore"} {
+		for _, r := range idx.Geocode(query, 5) {
+			if r.Kind != KindPlace {
+				t.Errorf("Geocode(%q) = %+v, want no address or street", query, r)
+			}
+		}
+	}
+	// Other streets sharing only the suffix don't match
+	for _, r := range idx.Geocode("1 Hill Road", 5) {
+		if r.POIID == "raffles" || r.POIID == "gallery" {
+			t.Errorf("Geocode(%q) matched %q", "1 Hill Road", r.POIID)
+		}
+	}
+}
+
+func TestGeocodeRemove(t *testing.T) {
+	idx := testIndex()
+	idx.Remove("sccci")
+	for _, r := range idx.Geocode("60 Hill Street", 5) {
+		if r.POIID == "sccci" {
+			t.Errorf("removed POI still returned: %+v", r)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package geometry
+
+import "math"
+
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// Bearing returns the initial great circle bearing in degrees (0-360,
+// clockwise from north) for travelling from the first point to the second
+func Bearing(lon1, lat1, lon2, lat2 float64) float64 {
+	lat1Rad, lat2Rad := lat1*math.Pi/180, lat2*math.Pi/180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// Compass returns the 8-point compass label for a bearing, e.g. "NE"
+func Compass(bearing float64) string {
+	index := int(math.Round(math.Mod(bearing+360, 360)/45)) % len(compassPoints)
+	return compassPoints[index]
+}
@@ -0,0 +1,15 @@
+package geometry
+
+import "math"
+
+// EarthRadiusMeters is the mean Earth radius used for great circle distances
+const EarthRadiusMeters = 6371008.8
+
+// Distance returns the great circle distance in meters between two lon/lat points
+func Distance(lon1, lat1, lon2, lat2 float64) float64 {
+	lat1Rad, lat2Rad := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := lat2Rad - lat1Rad
+	dLon := (lon2 - lon1) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1Rad)*math.Cos(lat2Rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,47 @@
+package geometry
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+}
+
+// ParseArea decodes a GeoJSON Polygon or MultiPolygon, bare or wrapped in a
+// Feature, into a validated MultiPolygon
+func ParseArea(data []byte) (MultiPolygon, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	if obj.Type == "Feature" {
+		if obj.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		obj = *obj.Geometry
+	}
+
+	var area MultiPolygon
+	switch obj.Type {
+	case "Polygon":
+		var polygon Polygon
+		if err := json.Unmarshal(obj.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %v", err)
+		}
+		area = MultiPolygon{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(obj.Coordinates, &area); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("expected a Polygon or MultiPolygon, got %q", obj.Type)
+	}
+	if err := area.Validate(); err != nil {
+		return nil, err
+	}
+	return area, nil
+}
@@ -0,0 +1,159 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point is a lon/lat pair, in GeoJSON order
+type Point [2]float64
+
+// Ring is a closed line string, the first and last points are equal
+type Ring []Point
+
+// Polygon is an outer ring followed by any number of holes
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons, a point inside any of them is inside
+type MultiPolygon []Polygon
+
+// Bounds is the lon/lat extent of a geometry
+type Bounds struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// validate checks that the ring is closed and has enough points to enclose an area
+func (r Ring) validate() error {
+	if len(r) < 4 {
+		return fmt.Errorf("a linear ring needs at least 4 positions, got %d", len(r))
+	}
+	if r[0] != r[len(r)-1] {
+		return fmt.Errorf("a linear ring must start and end with the same position")
+	}
+	for _, p := range r {
+		if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+			return fmt.Errorf("position %v is out of range", p)
+		}
+	}
+	return nil
+}
+
+// contains reports whether a point is strictly inside the ring, using the
+// even-odd ray casting rule with straight edges in lon/lat space
+func (r Ring) contains(lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// onBoundary reports whether a point lies on one of the ring's edges
+func (r Ring) onBoundary(lon, lat float64) bool {
+	const epsilon = 1e-12
+	for i := 1; i < len(r); i++ {
+		a, b := r[i-1], r[i]
+		cross := (b[0]-a[0])*(lat-a[1]) - (b[1]-a[1])*(lon-a[0])
+		if math.Abs(cross) > epsilon {
+			continue
+		}
+		if lon >= math.Min(a[0], b[0]) && lon <= math.Max(a[0], b[0]) &&
+			lat >= math.Min(a[1], b[1]) && lat <= math.Max(a[1], b[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks every ring of the polygon
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("a polygon needs an outer ring")
+	}
+	for i, ring := range p {
+		if err := ring.validate(); err != nil {
+			return fmt.Errorf("ring %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Contains reports whether a point is inside the outer ring and outside
+// every hole. Points on any ring's boundary count as inside.
+func (p Polygon) Contains(lon, lat float64) bool {
+	if len(p) == 0 {
+		return false
+	}
+	for _, ring := range p {
+		if ring.onBoundary(lon, lat) {
+			return true
+		}
+	}
+	if !p[0].contains(lon, lat) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.contains(lon, lat) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bounds returns the extent of the outer ring
+func (p Polygon) Bounds() Bounds {
+	b := Bounds{MinLon: math.Inf(1), MinLat: math.Inf(1), MaxLon: math.Inf(-1), MaxLat: math.Inf(-1)}
+	if len(p) == 0 {
+		return b
+	}
+	for _, pt := range p[0] {
+		b.MinLon = math.Min(b.MinLon, pt[0])
+		b.MinLat = math.Min(b.MinLat, pt[1])
+		b.MaxLon = math.Max(b.MaxLon, pt[0])
+		b.MaxLat = math.Max(b.MaxLat, pt[1])
+	}
+	return b
+}
+
+// Validate checks every polygon
+func (m MultiPolygon) Validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("a multipolygon needs at least one polygon")
+	}
+	for i, polygon := range m {
+		if err := polygon.Validate(); err != nil {
+			return fmt.Errorf("polygon %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Contains reports whether a point is inside any of the polygons
+func (m MultiPolygon) Contains(lon, lat float64) bool {
+	for _, polygon := range m {
+		if polygon.Contains(lon, lat) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds returns the combined extent of all polygons
+func (m MultiPolygon) Bounds() Bounds {
+	b := Bounds{MinLon: math.Inf(1), MinLat: math.Inf(1), MaxLon: math.Inf(-1), MaxLat: math.Inf(-1)}
+	for _, polygon := range m {
+		pb := polygon.Bounds()
+		b.MinLon = math.Min(b.MinLon, pb.MinLon)
+		b.MinLat = math.Min(b.MinLat, pb.MinLat)
+		b.MaxLon = math.Max(b.MaxLon, pb.MaxLon)
+		b.MaxLat = math.Max(b.MaxLat, pb.MaxLat)
+	}
+	return b
+}
@@ -10,6 +10,7 @@ require (
 	github.com/redis/go-redis/v9 v9.10.0
 	go.mongodb.org/mongo-driver v1.17.3
 	golang.org/x/crypto v0.38.0
+	golang.org/x/text v0.25.0
 )
 
 require (
@@ -23,5 +24,4 @@ require (
 	github.com/xdg-go/stringprep v1.0.4 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
 	golang.org/x/sync v0.14.0 // indirect
-	golang.org/x/text v0.25.0 // indirect
 )
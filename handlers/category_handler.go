@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/services"
+	"go-server/taxonomy"
+	"net/http"
+)
+
+type CategoryHandler struct {
+	geoService *services.GeoService
+}
+
+type CategoriesResponse struct {
+	Categories []taxonomy.Node `json:"categories"`
+}
+
+func NewCategoryHandler(geoService *services.GeoService) *CategoryHandler {
+	return &CategoryHandler{geoService: geoService}
+}
+
+// GetCategories returns the category tree. Category IDs are the POI types and
+// can be passed to /pois as type, or as category to include subcategories.
+func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CategoriesResponse{Categories: h.geoService.Categories()})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/services"
+	"go-server/utils/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type CellHandler struct {
+	geoService *services.GeoService
+}
+
+type CellInfo struct {
+	ID     string     `json:"id"`
+	Center [2]float64 `json:"center"` // lon, lat
+	BBox   [4]float64 `json:"bbox"`
+}
+
+type CellPOIsResponse struct {
+	Cell  CellInfo     `json:"cell"`
+	POIs  []models.POI `json:"pois"`
+	Count int          `json:"count"`
+}
+
+func NewCellHandler(geoService *services.GeoService) *CellHandler {
+	return &CellHandler{geoService: geoService}
+}
+
+// GetCellPOIs returns the POIs inside a geohash (e.g. w21z7) or hex cell (e.g. hex:15:43347:626)
+func (h *CellHandler) GetCellPOIs(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	c, pois, err := h.geoService.FindPOIsInCell(r.Context(), mux.Vars(r)["id"], r.URL.Query().Get("type"), limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	lon, lat := c.Center()
+	minLon, minLat, maxLon, maxLat := c.Bounds()
+	w.Header().Set("Content-Type", "application/json")
+	response := CellPOIsResponse{
+		Cell: CellInfo{
+			ID:     c.ID(),
+			Center: [2]float64{lon, lat},
+			BBox:   [4]float64{minLon, minLat, maxLon, maxLat},
+		},
+		POIs:  pois,
+		Count: len(pois),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/services"
+	"go-server/utils/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type CheckinHandler struct {
+	checkinService *services.CheckinService
+}
+
+type CheckinsResponse struct {
+	Checkins   []models.Checkin `json:"checkins"`
+	Count      int              `json:"count"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func NewCheckinHandler(checkinService *services.CheckinService) *CheckinHandler {
+	return &CheckinHandler{checkinService: checkinService}
+}
+
+func (h *CheckinHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	checkin, err := h.checkinService.CheckIn(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(checkin)
+}
+
+// GetUserCheckins lists the current user's check-ins. Pass next_cursor as
+// ?cursor= to get the next page.
+func (h *CheckinHandler) GetUserCheckins(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(r)
+	if !ok {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	checkins, nextCursor, err := h.checkinService.GetUserCheckins(r.Context(), r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CheckinsResponse{Checkins: checkins, Count: len(checkins), NextCursor: nextCursor})
+}
+
+// GetPOICheckins lists check-ins at a POI by the current user and their friends
+func (h *CheckinHandler) GetPOICheckins(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(r)
+	if !ok {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	checkins, nextCursor, err := h.checkinService.GetPOICheckins(r.Context(), mux.Vars(r)["id"], r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CheckinsResponse{Checkins: checkins, Count: len(checkins), NextCursor: nextCursor})
+}
+
+// parseLimit reads an optional ?limit=, 0 when it's missing
+func parseLimit(r *http.Request) (int, bool) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		return 0, false
+	}
+	return limit, true
+}
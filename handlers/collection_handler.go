@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/services"
+	"go-server/utils/errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type CollectionHandler struct {
+	collectionService *services.CollectionService
+}
+
+type CollectionsResponse struct {
+	Collections []models.Collection `json:"collections"`
+	Count       int                 `json:"count"`
+}
+
+func NewCollectionHandler(collectionService *services.CollectionService) *CollectionHandler {
+	return &CollectionHandler{collectionService: collectionService}
+}
+
+// ListCollections lists the current user's collections, or with ?owner= the
+// collections of another user that the current user may see
+func (h *CollectionHandler) ListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := h.collectionService.ListCollections(r.Context(), r.URL.Query().Get("owner"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CollectionsResponse{Collections: collections, Count: len(collections)})
+}
+
+func (h *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	var input services.CollectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	collection, err := h.collectionService.CreateCollection(r.Context(), input)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(collection)
+}
+
+func (h *CollectionHandler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("userID").(string)
+	collection, err := h.collectionService.GetCollection(r.Context(), mux.Vars(r)["id"], userID)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+func (h *CollectionHandler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	var input services.CollectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(r.Context(), mux.Vars(r)["id"], input)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+func (h *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	err := h.collectionService.DeleteCollection(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Collection deleted"})
+}
+
+func (h *CollectionHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		POIID    string `json:"poi_id"`
+		Note     string `json:"note"`
+		Position *int   `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.POIID == "" {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+	position := -1 // Append by default
+	if input.Position != nil {
+		position = *input.Position
+	}
+
+	err := h.collectionService.AddItem(r.Context(), mux.Vars(r)["id"], input.POIID, input.Note, position)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Item added"})
+}
+
+func (h *CollectionHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Note string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	vars := mux.Vars(r)
+	err := h.collectionService.UpdateItemNote(r.Context(), vars["id"], vars["poiID"], input.Note)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Item updated"})
+}
+
+func (h *CollectionHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	err := h.collectionService.RemoveItem(r.Context(), vars["id"], vars["poiID"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Item removed"})
+}
+
+func (h *CollectionHandler) ReorderItems(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		POIIDs []string `json:"poi_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	err := h.collectionService.ReorderItems(r.Context(), mux.Vars(r)["id"], input.POIIDs)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Collection reordered"})
+}
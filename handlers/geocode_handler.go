@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/geocode"
+	"go-server/middleware"
+	"go-server/services"
+	"go-server/utils/errors"
+	"net/http"
+	"strconv"
+)
+
+type GeocodeHandler struct {
+	geoService *services.GeoService
+}
+
+func NewGeocodeHandler(geoService *services.GeoService) *GeocodeHandler {
+	return &GeocodeHandler{geoService: geoService}
+}
+
+// GeocodeResponse lists the locations matching a geocoding query
+type GeocodeResponse struct {
+	Query   string           `json:"query"`
+	Results []geocode.Result `json:"results"`
+	Count   int              `json:"count"`
+}
+
+// Geocode resolves ?q= (an address, postal code or place name) to coordinates
+func (h *GeocodeHandler) Geocode(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	query := r.URL.Query().Get("q")
+	results, err := h.geoService.Geocode(r.Context(), query, limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GeocodeResponse{Query: query, Results: results, Count: len(results)})
+}
+
+// ReverseGeocode describes ?lat=&lon= by its nearest POI, street and area
+func (h *GeocodeHandler) ReverseGeocode(w http.ResponseWriter, r *http.Request) {
+	lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	if err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+	lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	place, err := h.geoService.ReverseGeocode(r.Context(), lon, lat)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(place)
+}
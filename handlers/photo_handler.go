@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"go-server/middleware"
+	"go-server/services"
+	"go-server/utils/errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead is allowed on top of the photo size for the form encoding
+const multipartOverhead = 64 << 10
+
+type PhotoHandler struct {
+	photoService *services.PhotoService
+}
+
+func NewPhotoHandler(photoService *services.PhotoService) *PhotoHandler {
+	return &PhotoHandler{photoService: photoService}
+}
+
+// UploadPhoto takes a multipart form with the image in the "photo" field
+func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	maxBytes := h.photoService.MaxBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+	file, _, err := r.FormFile("photo")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			middleware.WriteError(w, errors.NewAPIError("PHOTO_TOO_LARGE", "Photo is too large", http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("maximum size is %d bytes", maxBytes)))
+			return
+		}
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+	defer file.Close()
+	// Read one byte past the limit so the service can tell the file is too large
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	photo, err := h.photoService.UploadPhoto(r.Context(), mux.Vars(r)["id"], data)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(photo)
+}
+
+func (h *PhotoHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	err := h.photoService.DeletePhoto(r.Context(), vars["id"], vars["photoID"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photo deleted"})
+}
@@ -2,24 +2,73 @@ package handlers
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"go-server/cluster"
+	"go-server/exporters"
+	"go-server/geometry"
+	"go-server/importers"
 	"go-server/middleware"
 	"go-server/models"
 	"go-server/services"
 	"go-server/utils/errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
+// maxImportBytes caps the size of an import upload, form encoding included
+const maxImportBytes = 64 << 20
+
+var errImportTooLarge = errors.NewAPIError("IMPORT_TOO_LARGE", "Import file is too large", http.StatusRequestEntityTooLarge,
+	fmt.Sprintf("maximum size is %d bytes", maxImportBytes))
+
 type POIHandler struct {
 	geoService *services.GeoService
 }
 
 type NearbyPOIResponse struct {
-	NearbyPOIs []models.POI `json:"nearby_pois"`
-	Count      int          `json:"count"`
-	Lat        float64      `json:"lat"`
-	Lon        float64      `json:"lon"`
-	Radius     float64      `json:"radius"`
+	NearbyPOIs []services.NearbyPOI `json:"nearby_pois"`
+	Count      int                  `json:"count"`
+	Lat        float64              `json:"lat"`
+	Lon        float64              `json:"lon"`
+	Radius     float64              `json:"radius"`
+	Units      string               `json:"units"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+type SearchPOIResponse struct {
+	Results []services.SearchResult `json:"results"`
+	Count   int                     `json:"count"`
+	Query   string                  `json:"query"`
+}
+
+type SuggestPOIResponse struct {
+	Suggestions []services.Suggestion `json:"suggestions"`
+	Prefix      string                `json:"prefix"`
+}
+
+type AreaPOIResponse struct {
+	POIs  []models.POI `json:"pois"`
+	Count int          `json:"count"`
+}
+
+type WithinPOIResponse struct {
+	POIs  []models.POI `json:"pois"`
+	Count int          `json:"count"`
+	BBox  [4]float64   `json:"bbox"`
+}
+
+type ClusterResponse struct {
+	Clusters []cluster.Cluster `json:"clusters"`
+	Count    int               `json:"count"`
+	Zoom     int               `json:"zoom"`
+	BBox     [4]float64        `json:"bbox"`
 }
 
 func NewPOIHandler(geoService *services.GeoService) *POIHandler {
@@ -42,9 +91,81 @@ func (h *POIHandler) GetNearbyPOIs(w http.ResponseWriter, r *http.Request) {
 		middleware.WriteError(w, errors.ErrInvalidInput)
 		return
 	}
-	poiType := r.URL.Query().Get("type")
+	units, err := services.ParseDistanceUnit(r.URL.Query().Get("units"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+	order, err := services.ParseNearbySort(r.URL.Query().Get("sort"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+	minRating := 0.0
+	if minRatingStr := r.URL.Query().Get("min_rating"); minRatingStr != "" {
+		minRating, err = strconv.ParseFloat(minRatingStr, 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	// open_now and open_at both filter on opening hours, so only one may be set
+	var openAt time.Time
+	if openNowStr := r.URL.Query().Get("open_now"); openNowStr != "" {
+		openNow, err := strconv.ParseBool(openNowStr)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		if openNow {
+			openAt = time.Now()
+		}
+	}
+	if openAtStr := r.URL.Query().Get("open_at"); openAtStr != "" {
+		if !openAt.IsZero() {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		openAt, err = time.Parse(time.RFC3339, openAtStr)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	tagsMode, err := services.ParseTagsMode(r.URL.Query().Get("tags_mode"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
 
-	pois, err := h.geoService.FindNearbyPOIs(r.Context(), lat, lon, radius, poiType)
+	pois, nextCursor, err := h.geoService.FindNearbyPOIs(r.Context(), services.NearbyPOIQuery{
+		Lat:       lat,
+		Lon:       lon,
+		Radius:    units.ToMeters(radius),
+		Units:     units,
+		Type:      r.URL.Query().Get("type"),
+		MinRating: minRating,
+		OpenAt:    openAt,
+		Category:  r.URL.Query().Get("category"),
+		Tags: services.TagFilter{
+			Tags:    services.ParseTags(r.URL.Query().Get("tags")),
+			Mode:    tagsMode,
+			Exclude: services.ParseTags(r.URL.Query().Get("exclude_tags")),
+		},
+		Sort:   order,
+		Limit:  limit,
+		Cursor: r.URL.Query().Get("cursor"),
+	})
 	if err != nil {
 		middleware.WriteError(w, err)
 		return
@@ -58,6 +179,388 @@ func (h *POIHandler) GetNearbyPOIs(w http.ResponseWriter, r *http.Request) {
 		Lat:        lat,
 		Lon:        lon,
 		Radius:     radius,
+		Units:      string(units),
+		NextCursor: nextCursor,
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *POIHandler) GetPOIsWithin(w http.ResponseWriter, r *http.Request) {
+	box, err := services.ParseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	pois, err := h.geoService.FindPOIsWithin(r.Context(), box, r.URL.Query().Get("type"), limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := WithinPOIResponse{
+		POIs:  pois,
+		Count: len(pois),
+		BBox:  [4]float64{box.MinLon, box.MinLat, box.MaxLon, box.MaxLat},
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPOIClusters returns map clusters inside a bbox at a zoom level
+func (h *POIHandler) GetPOIClusters(w http.ResponseWriter, r *http.Request) {
+	box, err := services.ParseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+	zoom, err := strconv.Atoi(r.URL.Query().Get("zoom"))
+	if err != nil || zoom < 0 || zoom > 30 {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	clusters, err := h.geoService.FindClusters(r.Context(), box, zoom)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := ClusterResponse{
+		Clusters: clusters,
+		Count:    len(clusters),
+		Zoom:     zoom,
+		BBox:     [4]float64{box.MinLon, box.MinLat, box.MaxLon, box.MaxLat},
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPOITile serves /tiles/pois/{z}/{x}/{y}.mvt as a Mapbox Vector Tile
+func (h *POIHandler) GetPOITile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	z, errZ := strconv.Atoi(vars["z"])
+	x, errX := strconv.Atoi(vars["x"])
+	y, errY := strconv.Atoi(vars["y"])
+	if errZ != nil || errX != nil || errY != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	tile, err := h.geoService.GetPOITile(r.Context(), z, x, y)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	w.Write(tile)
+}
+
+// GetPOIsInArea takes a GeoJSON Polygon or MultiPolygon (optionally wrapped in a Feature) as the request body
+func (h *POIHandler) GetPOIsInArea(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+	if err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+	area, err := geometry.ParseArea(body)
+	if err != nil {
+		middleware.WriteError(w, errors.NewAPIError("INVALID_AREA", "Invalid area geometry", http.StatusBadRequest, err.Error()))
+		return
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	pois, err := h.geoService.FindPOIsInArea(r.Context(), area, r.URL.Query().Get("type"), limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AreaPOIResponse{POIs: pois, Count: len(pois)})
+}
+
+func (h *POIHandler) SearchPOIs(w http.ResponseWriter, r *http.Request) {
+	query := services.SearchPOIsQuery{
+		Text: r.URL.Query().Get("q"),
+		Type: r.URL.Query().Get("type"),
+	}
+	// Proximity boosting is optional, but needs both coordinates
+	if r.URL.Query().Has("lat") || r.URL.Query().Has("lon") {
+		lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		query.Near = &models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		query.Limit = limit
+	}
+
+	results, err := h.geoService.SearchPOIs(r.Context(), query)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SearchPOIResponse{Results: results, Count: len(results), Query: query.Text})
+}
+
+func (h *POIHandler) SuggestPOIs(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var near *models.GeoPoint
+	if r.URL.Query().Has("lat") || r.URL.Query().Has("lon") {
+		lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		near = &models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	suggestions, err := h.geoService.SuggestPOIs(r.Context(), prefix, near, limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SuggestPOIResponse{Suggestions: suggestions, Prefix: prefix})
+}
+
+func (h *POIHandler) GetPOI(w http.ResponseWriter, r *http.Request) {
+	poi, err := h.geoService.GetPOI(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(poi)
+}
+
+func (h *POIHandler) CreatePOI(w http.ResponseWriter, r *http.Request) {
+	var input models.POI
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	poi, err := h.geoService.CreatePOI(r.Context(), input)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(poi)
+}
+
+func (h *POIHandler) UpdatePOI(w http.ResponseWriter, r *http.Request) {
+	var input models.POI
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	poi, err := h.geoService.UpdatePOI(r.Context(), mux.Vars(r)["id"], input)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(poi)
+}
+
+func (h *POIHandler) PatchPOI(w http.ResponseWriter, r *http.Request) {
+	var input services.POIPatch
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	poi, err := h.geoService.PatchPOI(r.Context(), mux.Vars(r)["id"], input)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(poi)
+}
+
+func (h *POIHandler) DeletePOI(w http.ResponseWriter, r *http.Request) {
+	err := h.geoService.DeletePOI(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "POI deleted"})
+}
+
+// ImportPOIs accepts a multipart "file" upload or a raw request body. The
+// format comes from the "format" query parameter or the uploaded file name.
+func (h *POIHandler) ImportPOIs(w http.ResponseWriter, r *http.Request) {
+	// Every record is read into memory before it's written
+	if r.ContentLength > maxImportBytes {
+		middleware.WriteError(w, errImportTooLarge)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	format := r.URL.Query().Get("format")
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if stderrors.As(err, &maxBytesErr) {
+				middleware.WriteError(w, errImportTooLarge)
+				return
+			}
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		defer file.Close()
+		if format == "" {
+			format = importers.FormatFromFilename(header.Filename)
+		}
+		body = file
+	}
+	if format == "" {
+		middleware.WriteError(w, errors.NewAPIError("UNSUPPORTED_FORMAT", "Import format is required", http.StatusBadRequest))
+		return
+	}
+
+	report, err := h.geoService.ImportPOIs(r.Context(), format, body)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ExportPOIs streams POIs as GeoJSON, CSV, KML or GPX. lat/lon/radius and
+// type filter like GetNearbyPOIs, but are optional.
+func (h *POIHandler) ExportPOIs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "geojson"
+	}
+	exporter, err := exporters.Get(format)
+	if err != nil {
+		middleware.WriteError(w, errors.NewAPIError("UNSUPPORTED_FORMAT", "Unsupported export format", http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	var lat, lon, radius float64
+	if query.Has("lat") || query.Has("lon") || query.Has("radius") {
+		lat, err = strconv.ParseFloat(query.Get("lat"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		lon, err = strconv.ParseFloat(query.Get("lon"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		radius, err = strconv.ParseFloat(query.Get("radius"), 64)
+		if err != nil || radius <= 0 {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	// The writer is created on the first POI so that query errors can still
+	// be reported with a proper status code
+	var writer exporters.Writer
+	startWriter := func() error {
+		w.Header().Set("Content-Type", exporter.ContentType())
+		w.Header().Set("Content-Disposition", "attachment; filename=pois."+exporter.Extension())
+		writer, err = exporter.NewWriter(w)
+		return err
+	}
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = h.geoService.StreamPOIs(r.Context(), lat, lon, radius, query.Get("type"), func(poi models.POI) error {
+		if writer == nil {
+			if err := startWriter(); err != nil {
+				return err
+			}
+		}
+		if err := writer.WritePOI(poi); err != nil {
+			return err
+		}
+		if count++; flusher != nil && count%100 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if writer == nil {
+			middleware.WriteError(w, err)
+			return
+		}
+		// Headers are already sent, all we can do is cut the stream short
+		log.Printf("POI export failed after %d POIs: %v", count, err)
+		return
+	}
+	if writer == nil {
+		if err := startWriter(); err != nil {
+			log.Printf("POI export failed: %v", err)
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("POI export failed: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/services"
+	"go-server/utils/errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+}
+
+type ReviewsResponse struct {
+	Reviews    []models.Review `json:"reviews"`
+	Count      int             `json:"count"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func NewReviewHandler(reviewService *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// GetPOIReviews lists the reviews of a POI. Pass next_cursor as ?cursor= to
+// get the next page.
+func (h *ReviewHandler) GetPOIReviews(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(r)
+	if !ok {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	reviews, nextCursor, err := h.reviewService.GetPOIReviews(r.Context(), mux.Vars(r)["id"], r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReviewsResponse{Reviews: reviews, Count: len(reviews), NextCursor: nextCursor})
+}
+
+// SaveReview creates or edits the current user's review of a POI
+func (h *ReviewHandler) SaveReview(w http.ResponseWriter, r *http.Request) {
+	var input services.ReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		middleware.WriteError(w, errors.ErrInvalidInput)
+		return
+	}
+
+	review, created, err := h.reviewService.SaveReview(r.Context(), mux.Vars(r)["id"], input)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(review)
+}
+
+func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	err := h.reviewService.DeleteReview(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Review deleted"})
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"go-server/middleware"
+	"go-server/models"
 	"go-server/services"
 	"go-server/utils/errors"
 	"net/http"
 	"strconv"
+
+	"github.com/gorilla/mux"
 )
 
 type UserHandler struct {
@@ -20,6 +23,7 @@ type NearbyFriendsResponse struct {
 	Lat           float64                `json:"lat"`
 	Lon           float64                `json:"lon"`
 	Radius        float64                `json:"radius"`
+	Units         string                 `json:"units"`
 }
 
 type NearbyUsersResponse struct {
@@ -28,6 +32,12 @@ type NearbyUsersResponse struct {
 	Lat         float64                `json:"lat"`
 	Lon         float64                `json:"lon"`
 	Radius      float64                `json:"radius"`
+	Units       string                 `json:"units"`
+}
+
+type FavoritesResponse struct {
+	Favorites []services.FavoritePOI `json:"favorites"`
+	Count     int                    `json:"count"`
 }
 
 func NewUserHandler(userService *services.UserService, jwtSecret string) *UserHandler {
@@ -83,11 +93,16 @@ func (h *UserHandler) GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 		middleware.WriteError(w, errors.ErrInvalidInput)
 		return
 	}
+	units, err := services.ParseDistanceUnit(r.URL.Query().Get("units"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
 	if radius <= 0 {
-		radius = 3000 // Default radius in meters
+		radius = units.FromMeters(3000) // Default radius of 3km
 	}
 
-	users, err := h.userService.GetNearbyUsers(r.Context(), lat, lon, radius)
+	users, err := h.userService.GetNearbyUsers(r.Context(), lat, lon, units.ToMeters(radius), units)
 	if err != nil {
 		middleware.WriteError(w, err)
 		return
@@ -99,6 +114,7 @@ func (h *UserHandler) GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 		Lat:         lat,
 		Lon:         lon,
 		Radius:      radius,
+		Units:       string(units),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -122,11 +138,16 @@ func (h *UserHandler) GetNearbyFriends(w http.ResponseWriter, r *http.Request) {
 		middleware.WriteError(w, errors.ErrInvalidInput)
 		return
 	}
+	units, err := services.ParseDistanceUnit(r.URL.Query().Get("units"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
 	if radius <= 0 {
-		radius = 3000 // Default radius in meters
+		radius = units.FromMeters(3000) // Default radius of 3km
 	}
 
-	friends, err := h.userService.GetNearbyFriends(r.Context(), lat, lon, radius)
+	friends, err := h.userService.GetNearbyFriends(r.Context(), lat, lon, units.ToMeters(radius), units)
 	if err != nil {
 		middleware.WriteError(w, err)
 		return
@@ -138,6 +159,7 @@ func (h *UserHandler) GetNearbyFriends(w http.ResponseWriter, r *http.Request) {
 		Lat:           lat,
 		Lon:           lon,
 		Radius:        radius,
+		Units:         string(units),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -181,3 +203,57 @@ func (h *UserHandler) AcceptFriendRequest(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Friend request accepted"})
 }
+
+func (h *UserHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	err := h.userService.AddFavoritePOI(r.Context(), mux.Vars(r)["poiID"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Favorite added"})
+}
+
+func (h *UserHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	err := h.userService.RemoveFavoritePOI(r.Context(), mux.Vars(r)["poiID"])
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Favorite removed"})
+}
+
+// GetFavorites lists favorites, sorted by distance when lat and lon are given
+func (h *UserHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	var near *models.GeoPoint
+	if r.URL.Query().Has("lat") || r.URL.Query().Has("lon") {
+		lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+		if err != nil {
+			middleware.WriteError(w, errors.ErrInvalidInput)
+			return
+		}
+		near = &models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+	}
+	units, err := services.ParseDistanceUnit(r.URL.Query().Get("units"))
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	favorites, err := h.userService.GetFavoritePOIs(r.Context(), near, units)
+	if err != nil {
+		middleware.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FavoritesResponse{Favorites: favorites, Count: len(favorites)})
+}
@@ -0,0 +1,401 @@
+// Package hours parses and evaluates opening hours written in a subset of
+// the OpenStreetMap opening_hours syntax:
+//
+//	24/7
+//	Mo-Fr 09:00-17:00; Sa 10:00-14:00
+//	Mo-Su 10:00-12:00,13:00-22:00; Dec 25 off
+//	Fr-Sa 18:00-02:00
+//	Jan-Mar Mo-Fr 08:00-16:00, Sa 09:00-12:00
+//
+// Rules are separated by ";" and a later rule replaces the hours of earlier
+// rules on the days it matches, while a rule following "," adds to them.
+// Public and school holiday selectors (PH, SH) are accepted but never match
+// because holidays aren't known.
+package hours
+
+import (
+	"fmt"
+	"strings"
+)
+
+const minutesPerDay = 24 * 60
+
+var (
+	monthNames   = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	weekdayNames = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+)
+
+// Schedule is a parsed opening hours expression
+type Schedule struct {
+	rules []rule
+}
+
+type rule struct {
+	dates      []dateRange // Empty matches every date
+	weekdays   *[7]bool    // Indexed from Monday, nil matches every weekday
+	holidays   bool        // Only holiday selectors, never matches
+	spans      []span      // Empty means closed
+	additional bool        // Adds to earlier rules instead of replacing them
+}
+
+// dateRange is an inclusive range of month*100+day values, wrapping at the
+// end of the year when from > to
+type dateRange struct {
+	from, to int
+}
+
+// span is a range of minutes since midnight, end may run past midnight
+type span struct {
+	start, end int
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+// Parse parses an opening hours expression
+func Parse(s string) (*Schedule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty opening hours")
+	}
+	if strings.Contains(s, "||") {
+		return nil, fmt.Errorf("fallback rules (||) are not supported")
+	}
+	p := &parser{s: s}
+	schedule := &Schedule{}
+	additional := false
+	for {
+		r, err := p.rule()
+		if err != nil {
+			return nil, err
+		}
+		r.additional = additional
+		schedule.rules = append(schedule.rules, r)
+
+		p.skipSpace()
+		switch {
+		case p.eof():
+			return schedule, nil
+		case p.consume(";"):
+			additional = false
+		case p.consume(","):
+			additional = true
+		default:
+			return nil, p.errorf("expected ; or ,")
+		}
+	}
+}
+
+func (p *parser) rule() (rule, error) {
+	var r rule
+	p.skipSpace()
+	if p.consume("24/7") {
+		r.spans = []span{{0, minutesPerDay}}
+		return r, p.comment()
+	}
+
+	selectors := false
+	if p.atName(monthNames) {
+		dates, err := p.dates()
+		if err != nil {
+			return r, err
+		}
+		r.dates = dates
+		selectors = true
+		p.skipSpace()
+	}
+	if p.atName(weekdayNames) || p.atName([]string{"PH", "SH"}) {
+		if err := p.weekdays(&r); err != nil {
+			return r, err
+		}
+		selectors = true
+		p.skipSpace()
+	}
+
+	switch {
+	case p.consumeWord("off"), p.consumeWord("closed"):
+	case p.consumeWord("open"):
+		r.spans = []span{{0, minutesPerDay}}
+	case p.atDigit():
+		spans, err := p.spans()
+		if err != nil {
+			return r, err
+		}
+		r.spans = spans
+	case selectors && (p.eof() || p.at(";") || p.at(",") || p.at(`"`)):
+		// Selectors alone mean open all day
+		r.spans = []span{{0, minutesPerDay}}
+	default:
+		return r, p.errorf("expected a day or time selector")
+	}
+	return r, p.comment()
+}
+
+// dates parses a list like "Dec 24-26,Jan 01" or "Jan-Mar"
+func (p *parser) dates() ([]dateRange, error) {
+	var dates []dateRange
+	for {
+		fromMonth := p.name(monthNames)
+		if fromMonth < 0 {
+			return nil, p.errorf("expected a month")
+		}
+		from, to := (fromMonth+1)*100+1, (fromMonth+1)*100+31
+		if day, ok := p.day(); ok {
+			from, to = (fromMonth+1)*100+day, (fromMonth+1)*100+day
+		}
+		if p.consume("-") {
+			if toMonth := p.name(monthNames); toMonth >= 0 {
+				to = (toMonth+1)*100 + 31
+				if day, ok := p.day(); ok {
+					to = (toMonth+1)*100 + day
+				}
+			} else if day, ok := p.day(); ok {
+				to = from/100*100 + day
+			} else {
+				return nil, p.errorf("expected a month or day")
+			}
+		}
+		dates = append(dates, dateRange{from: from, to: to})
+
+		// A comma continues the list only when another month follows
+		save := p.pos
+		if !p.consume(",") {
+			return dates, nil
+		}
+		p.skipSpace()
+		if !p.atName(monthNames) {
+			p.pos = save
+			return dates, nil
+		}
+	}
+}
+
+// day parses a day of the month after a month name, leaving times alone
+func (p *parser) day() (int, bool) {
+	save := p.pos
+	p.skipSpace()
+	start := p.pos
+	for p.atDigit() {
+		p.pos++
+	}
+	digits := p.s[start:p.pos]
+	if digits == "" || len(digits) > 2 || p.at(":") {
+		p.pos = save
+		return 0, false
+	}
+	day := 0
+	for _, c := range digits {
+		day = day*10 + int(c-'0')
+	}
+	if day < 1 || day > 31 {
+		p.pos = save
+		return 0, false
+	}
+	return day, true
+}
+
+// weekdays parses a list like "Mo-Fr,Su" or "Sa,PH"
+func (p *parser) weekdays(r *rule) error {
+	var days [7]bool
+	anyWeekday := false
+	for {
+		switch {
+		case p.consume("PH"), p.consume("SH"):
+		default:
+			from := p.name(weekdayNames)
+			if from < 0 {
+				return p.errorf("expected a weekday")
+			}
+			to := from
+			if p.consume("-") {
+				if to = p.name(weekdayNames); to < 0 {
+					return p.errorf("expected a weekday")
+				}
+			}
+			if p.at("[") {
+				return p.errorf("nth weekday selectors are not supported")
+			}
+			// Ranges like Fr-Mo wrap around the week
+			for d := from; ; d = (d + 1) % 7 {
+				days[d] = true
+				if d == to {
+					break
+				}
+			}
+			anyWeekday = true
+		}
+
+		save := p.pos
+		if !p.consume(",") {
+			break
+		}
+		p.skipSpace()
+		if !p.atName(weekdayNames) && !p.atName([]string{"PH", "SH"}) {
+			p.pos = save
+			break
+		}
+	}
+	r.weekdays = &days
+	r.holidays = !anyWeekday
+	return nil
+}
+
+// spans parses a list like "09:00-12:00,13:00-17:30" or "18:00+"
+func (p *parser) spans() ([]span, error) {
+	var spans []span
+	for {
+		start, err := p.time()
+		if err != nil {
+			return nil, err
+		}
+		// An open end like "18:00+" is taken as until midnight
+		end := minutesPerDay
+		if !p.consume("+") {
+			if !p.consume("-") {
+				return nil, p.errorf("expected -")
+			}
+			if end, err = p.time(); err != nil {
+				return nil, err
+			}
+		}
+		if start >= minutesPerDay {
+			return nil, p.errorf("start time past 24:00")
+		}
+		if end <= start {
+			end += minutesPerDay
+		}
+		spans = append(spans, span{start: start, end: end})
+
+		save := p.pos
+		if !p.consume(",") {
+			return spans, nil
+		}
+		p.skipSpace()
+		if !p.atDigit() {
+			p.pos = save
+			return spans, nil
+		}
+	}
+}
+
+// time parses HH:MM into minutes since midnight. Hours up to 48 are allowed
+// for times past midnight.
+func (p *parser) time() (int, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.atDigit() {
+		p.pos++
+	}
+	hours := p.s[start:p.pos]
+	if hours == "" || len(hours) > 2 || !p.consume(":") {
+		return 0, p.errorf("expected a time")
+	}
+	minStart := p.pos
+	for p.atDigit() {
+		p.pos++
+	}
+	minutes := p.s[minStart:p.pos]
+	if len(minutes) != 2 {
+		return 0, p.errorf("expected a time")
+	}
+	h := atoi(hours)
+	m := atoi(minutes)
+	if h > 48 || m > 59 {
+		return 0, p.errorf("invalid time")
+	}
+	return h*60 + m, nil
+}
+
+// comment skips a trailing "quoted comment"
+func (p *parser) comment() error {
+	p.skipSpace()
+	if !p.consume(`"`) {
+		return nil
+	}
+	end := strings.IndexByte(p.s[p.pos:], '"')
+	if end < 0 {
+		return p.errorf("unterminated comment")
+	}
+	p.pos += end + 1
+	return nil
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) at(prefix string) bool {
+	return strings.HasPrefix(p.s[p.pos:], prefix)
+}
+
+func (p *parser) atDigit() bool {
+	return !p.eof() && p.s[p.pos] >= '0' && p.s[p.pos] <= '9'
+}
+
+func (p *parser) consume(prefix string) bool {
+	if p.at(prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// consumeWord consumes a keyword, case insensitively and only as a whole word
+func (p *parser) consumeWord(word string) bool {
+	end := p.pos + len(word)
+	if end > len(p.s) || !strings.EqualFold(p.s[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.s) && isLetter(p.s[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+// atName reports whether one of names starts at the current position as a whole word
+func (p *parser) atName(names []string) bool {
+	save := p.pos
+	found := p.name(names) >= 0
+	p.pos = save
+	return found
+}
+
+// name consumes one of names and returns its index, or -1
+func (p *parser) name(names []string) int {
+	for i, name := range names {
+		end := p.pos + len(name)
+		if p.at(name) && (end == len(p.s) || !isLetter(p.s[end])) {
+			p.pos = end
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(message string) error {
+	if p.eof() {
+		return fmt.Errorf("%s at end of %q", message, p.s)
+	}
+	return fmt.Errorf("%s at %q", message, p.s[p.pos:])
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func atoi(digits string) int {
+	n := 0
+	for _, c := range digits {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
@@ -0,0 +1,66 @@
+package hours
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAccepts(t *testing.T) {
+	tests := []string{
+		"24/7",
+		"Mo-Fr 09:00-17:00; Sa 10:00-14:00",
+		"Mo-Su 10:00-12:00,13:00-22:00; Dec 25 off",
+		"Fr-Sa 18:00-02:00",
+		"Fr-Mo 10:00-12:00",
+		"Dec-Feb 10:00-12:00",
+		"Jan-Mar Mo-Fr 08:00-16:00, Sa 09:00-12:00",
+		"Dec 24-26,Jan 01 off",
+		"Sa-Su",
+		"Mo-Su 18:00+",
+		"18:00+",
+		"Sa,PH 10:00-14:00",
+		"SH off",
+		"Mo closed",
+		"Mo-Fr open",
+		`Mo-Fr 09:00-17:00 "by appointment"`,
+		"Mo 22:00-26:00",
+	}
+	for _, s := range tests {
+		if _, err := Parse(s); err != nil {
+			t.Errorf("Parse(%q) = %v, want no error", s, err)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"Mo-Fr 09:00-17:00 || closed", "||"},
+		{"Mo[1] 10:00-12:00", "nth weekday"},
+		{"Mo 25:00-26:00", "past 24:00"},
+		{"Mo 10:00-49:00", "invalid time"},
+		{"Mo 10:60-12:00", "invalid time"},
+		{"25:00", "expected -"},
+		{`Mo 10:00-12:00 "unterminated`, "unterminated comment"},
+		{"sunrise-sunset", "expected a day or time selector"},
+		{"Mo-Fr sunrise-sunset", "expected a day or time selector"},
+		{"Mo-Fr 9-17", "expected a time"},
+		{"Mo-Xx 10:00-12:00", "expected a weekday"},
+		{"Mo 10:00-12:00 Tu", "expected ; or ,"},
+		{"Jan- 10:00-12:00", "expected a month or day"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.in)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error containing %q", tt.in, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want error containing %q", tt.in, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package hours
+
+import "time"
+
+// OpenAt reports whether the schedule is open at t, read in t's location
+func (s *Schedule) OpenAt(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, sp := range s.spansOn(t) {
+		if sp.start <= minute && minute < sp.end {
+			return true
+		}
+	}
+	// Spans of the previous day can run past midnight
+	for _, sp := range s.spansOn(t.AddDate(0, 0, -1)) {
+		if sp.start <= minute+minutesPerDay && minute+minutesPerDay < sp.end {
+			return true
+		}
+	}
+	return false
+}
+
+// spansOn returns the opening spans of the day of t
+func (s *Schedule) spansOn(t time.Time) []span {
+	var spans []span
+	for _, r := range s.rules {
+		if !r.matches(t) {
+			continue
+		}
+		if r.additional {
+			spans = append(spans, r.spans...)
+		} else {
+			spans = append([]span(nil), r.spans...)
+		}
+	}
+	return spans
+}
+
+func (r rule) matches(t time.Time) bool {
+	if r.holidays {
+		return false
+	}
+	if r.weekdays != nil && !r.weekdays[(int(t.Weekday())+6)%7] {
+		return false
+	}
+	if len(r.dates) == 0 {
+		return true
+	}
+	monthDay := int(t.Month())*100 + t.Day()
+	for _, d := range r.dates {
+		if d.contains(monthDay) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d dateRange) contains(monthDay int) bool {
+	if d.from <= d.to {
+		return d.from <= monthDay && monthDay <= d.to
+	}
+	return monthDay >= d.from || monthDay <= d.to
+}
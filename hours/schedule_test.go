@@ -0,0 +1,102 @@
+package hours
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a time in UTC. 2024-01-05 is a Friday.
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestOpenAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours string
+		at    time.Time
+		want  bool
+	}{
+		{"24/7 midnight", "24/7", at(2024, 1, 5, 0, 0), true},
+		{"24/7 late", "24/7", at(2024, 1, 7, 23, 59), true},
+
+		{"within span", "Mo-Fr 09:00-17:00", at(2024, 1, 5, 9, 0), true},
+		{"end is exclusive", "Mo-Fr 09:00-17:00", at(2024, 1, 5, 17, 0), false},
+		{"weekend closed", "Mo-Fr 09:00-17:00", at(2024, 1, 6, 12, 0), false},
+
+		{"semicolon replaces", "Mo-Fr 09:00-17:00; We 12:00-14:00", at(2024, 1, 3, 10, 0), false},
+		{"semicolon replacement hours", "Mo-Fr 09:00-17:00; We 12:00-14:00", at(2024, 1, 3, 13, 0), true},
+		{"semicolon leaves other days", "Mo-Fr 09:00-17:00; We 12:00-14:00", at(2024, 1, 2, 10, 0), true},
+		{"comma adds", "Mo-Fr 09:00-12:00, We 14:00-16:00", at(2024, 1, 3, 10, 0), true},
+		{"comma added hours", "Mo-Fr 09:00-12:00, We 14:00-16:00", at(2024, 1, 3, 15, 0), true},
+		{"comma only on its days", "Mo-Fr 09:00-12:00, We 14:00-16:00", at(2024, 1, 2, 15, 0), false},
+		{"split spans gap", "Mo-Su 10:00-12:00,13:00-22:00", at(2024, 1, 5, 12, 30), false},
+
+		{"overnight evening", "Fr-Sa 18:00-02:00", at(2024, 1, 5, 19, 0), true},
+		{"overnight before start", "Fr-Sa 18:00-02:00", at(2024, 1, 5, 17, 0), false},
+		{"overnight Saturday morning", "Fr-Sa 18:00-02:00", at(2024, 1, 6, 1, 0), true},
+		{"overnight Sunday morning", "Fr-Sa 18:00-02:00", at(2024, 1, 7, 1, 59), true},
+		{"overnight ends", "Fr-Sa 18:00-02:00", at(2024, 1, 7, 2, 0), false},
+		{"overnight Monday morning", "Fr-Sa 18:00-02:00", at(2024, 1, 8, 1, 0), false},
+		{"overnight Friday morning", "Fr-Sa 18:00-02:00", at(2024, 1, 5, 1, 0), false},
+		{"hours past 24", "Mo 22:00-26:00", at(2024, 1, 9, 1, 0), true},
+
+		{"wrapping weekdays Sunday", "Fr-Mo 10:00-12:00", at(2024, 1, 7, 11, 0), true},
+		{"wrapping weekdays Monday", "Fr-Mo 10:00-12:00", at(2024, 1, 8, 11, 0), true},
+		{"wrapping weekdays Tuesday", "Fr-Mo 10:00-12:00", at(2024, 1, 9, 11, 0), false},
+		{"wrapping months January", "Dec-Feb 10:00-12:00", at(2024, 1, 15, 11, 0), true},
+		{"wrapping months December", "Dec-Feb 10:00-12:00", at(2024, 12, 1, 11, 0), true},
+		{"wrapping months end of February", "Dec-Feb 10:00-12:00", at(2024, 2, 29, 11, 0), true},
+		{"wrapping months March", "Dec-Feb 10:00-12:00", at(2024, 3, 1, 11, 0), false},
+		{"wrapping months November", "Dec-Feb 10:00-12:00", at(2024, 11, 30, 11, 0), false},
+		{"months with weekdays", "Jan-Mar Mo-Fr 08:00-16:00", at(2024, 1, 6, 10, 0), false},
+
+		{"day off", "Mo-Su 10:00-22:00; Dec 25 off", at(2024, 12, 25, 12, 0), false},
+		{"day before day off", "Mo-Su 10:00-22:00; Dec 25 off", at(2024, 12, 24, 12, 0), true},
+		{"day range off", "Mo-Su 10:00-22:00; Dec 24-26 off", at(2024, 12, 26, 12, 0), false},
+
+		{"PH never matches", "PH 10:00-12:00", at(2024, 1, 1, 11, 0), false},
+		{"PH off never closes", "Mo-Su 10:00-20:00; PH off", at(2024, 1, 1, 11, 0), true},
+		{"SH off never closes", "Mo-Su 10:00-20:00; SH off", at(2024, 1, 1, 11, 0), true},
+		{"weekday with PH", "Sa,PH 10:00-14:00", at(2024, 1, 6, 11, 0), true},
+
+		{"bare selector open", "Sa-Su", at(2024, 1, 6, 3, 0), true},
+		{"bare selector closed", "Sa-Su", at(2024, 1, 8, 3, 0), false},
+		{"bare selector before rule", "Sa-Su; Mo 10:00-12:00", at(2024, 1, 7, 23, 0), true},
+
+		{"open end", "Mo-Su 18:00+", at(2024, 1, 5, 23, 59), true},
+		{"open end before start", "Mo-Su 18:00+", at(2024, 1, 5, 17, 0), false},
+		{"open end next day", "Mo-Su 18:00+", at(2024, 1, 6, 0, 30), false},
+
+		{"closed keyword", "Mo-Su 10:00-12:00; Mo closed", at(2024, 1, 8, 11, 0), false},
+		{"open keyword", "Mo open", at(2024, 1, 8, 23, 0), true},
+		{"comment ignored", `Mo-Fr 09:00-17:00 "by appointment"`, at(2024, 1, 5, 10, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.hours)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.hours, err)
+			}
+			if got := s.OpenAt(tt.at); got != tt.want {
+				t.Errorf("Parse(%q).OpenAt(%s) = %v, want %v", tt.hours, tt.at.Format("Mon 2006-01-02 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAtLocation(t *testing.T) {
+	s, err := Parse("Mo-Fr 09:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	singapore := time.FixedZone("SGT", 8*60*60)
+	// 02:00 UTC on Friday is 10:00 in Singapore
+	utc := at(2024, 1, 5, 2, 0)
+	if s.OpenAt(utc) {
+		t.Errorf("OpenAt(%s) = true, want false", utc)
+	}
+	if !s.OpenAt(utc.In(singapore)) {
+		t.Errorf("OpenAt(%s) = false, want true", utc.In(singapore))
+	}
+}
@@ -0,0 +1,223 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrMalformed = errors.New("malformed image")
+
+	exifHeader         = []byte("Exif\x00\x00")
+	xmpHeader          = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtensionHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	pngSignature       = []byte("\x89PNG\r\n\x1a\n")
+)
+
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+// TIFF field type sizes in bytes, indexed by type
+var tiffTypeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// StripGPS removes location metadata from a JPEG or PNG file. The tags of
+// the EXIF GPS directory are blanked in place, so orientation and camera
+// tags survive, and XMP packets, which can repeat the location, are dropped.
+// PNG eXIf chunks are dropped whole.
+func StripGPS(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	}
+	return data, nil
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	for pos := 2; ; {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, ErrMalformed
+		}
+		marker := data[pos+1]
+		// Start of scan, the rest is image data
+		if marker == 0xDA {
+			out.Write(data[pos:])
+			return out.Bytes(), nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		segment := data[pos:end]
+		if marker == 0xE1 {
+			payload := segment[4:]
+			switch {
+			case bytes.HasPrefix(payload, xmpHeader), bytes.HasPrefix(payload, xmpExtensionHeader):
+				pos = end
+				continue
+			case bytes.HasPrefix(payload, exifHeader):
+				segment = bytes.Clone(segment)
+				if err := blankGPS(segment[4+len(exifHeader):]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		out.Write(segment)
+		pos = end
+	}
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+12 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, ErrMalformed
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		chunkData := data[pos+8 : pos+8+length]
+		drop := chunkType == "eXIf" ||
+			(chunkType == "iTXt" && bytes.HasPrefix(chunkData, []byte("XML:com.adobe.xmp\x00")))
+		if !drop {
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	return out.Bytes(), nil
+}
+
+// tiff reads a TIFF structure as found in EXIF data
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func newTIFF(data []byte) (*tiff, uint32, error) {
+	if len(data) < 8 {
+		return nil, 0, ErrMalformed
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, ErrMalformed
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, 0, ErrMalformed
+	}
+	return t, t.order.Uint32(data[4:]), nil
+}
+
+// entries returns the offsets of the 12 byte entries of the directory at offset
+func (t *tiff) entries(offset uint32) ([]int, error) {
+	start := int(offset)
+	if offset == 0 || start+2 > len(t.data) {
+		return nil, ErrMalformed
+	}
+	count := int(t.order.Uint16(t.data[start:]))
+	if start+2+count*12 > len(t.data) {
+		return nil, ErrMalformed
+	}
+	entries := make([]int, count)
+	for i := range entries {
+		entries[i] = start + 2 + i*12
+	}
+	return entries, nil
+}
+
+// blankGPS zeroes the GPS directory of an EXIF TIFF structure, including the
+// values stored outside of it, and leaves the directory empty
+func blankGPS(data []byte) error {
+	t, ifd0, err := newTIFF(data)
+	if err != nil {
+		return err
+	}
+	entries, err := t.entries(ifd0)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if t.order.Uint16(t.data[entry:]) != tagGPSInfo {
+			continue
+		}
+		gpsOffset := t.order.Uint32(t.data[entry+8:])
+		gpsEntries, err := t.entries(gpsOffset)
+		if err != nil {
+			return err
+		}
+		for _, gpsEntry := range gpsEntries {
+			fieldType := int(t.order.Uint16(t.data[gpsEntry+2:]))
+			count := int(t.order.Uint32(t.data[gpsEntry+4:]))
+			if fieldType <= 0 || fieldType >= len(tiffTypeSizes) {
+				continue
+			}
+			// Values over 4 bytes live at an offset, the others inline
+			if size := tiffTypeSizes[fieldType] * count; size > 4 && count > 0 {
+				valueOffset := int(t.order.Uint32(t.data[gpsEntry+8:]))
+				if valueOffset+size <= len(t.data) {
+					clear(t.data[valueOffset : valueOffset+size])
+				}
+			}
+		}
+		// Zero the entry count, the entries and the next directory offset
+		start := int(gpsOffset)
+		clear(t.data[start:min(start+2+len(gpsEntries)*12+4, len(t.data))])
+	}
+	return nil
+}
+
+// Orientation returns the EXIF orientation of a JPEG file, 1 when unknown
+func Orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF && data[pos+1] != 0xDA; {
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		payload := data[pos+4 : end]
+		if data[pos+1] == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			t, ifd0, err := newTIFF(payload[len(exifHeader):])
+			if err != nil {
+				return 1
+			}
+			entries, err := t.entries(ifd0)
+			if err != nil {
+				return 1
+			}
+			for _, entry := range entries {
+				if t.order.Uint16(t.data[entry:]) == tagOrientation {
+					if o := int(t.order.Uint16(t.data[entry+8:])); o >= 1 && o <= 8 {
+						return o
+					}
+				}
+			}
+			return 1
+		}
+		pos = end
+	}
+	return 1
+}
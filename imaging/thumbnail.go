@@ -0,0 +1,79 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// thumbnailSamples is the sampling grid per side used to average the source
+// pixels behind each thumbnail pixel
+const thumbnailSamples = 4
+
+// Thumbnail scales an image down to fit within maxSize x maxSize, applying
+// the EXIF orientation so the result is upright. Images that already fit
+// are only reoriented.
+func Thumbnail(src image.Image, orientation, maxSize int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	// Orientations 5 to 8 swap width and height
+	outW, outH := srcW, srcH
+	if orientation >= 5 {
+		outW, outH = srcH, srcW
+	}
+	if outW > maxSize || outH > maxSize {
+		if outW >= outH {
+			outW, outH = maxSize, max(1, outH*maxSize/outW)
+		} else {
+			outW, outH = max(1, outW*maxSize/outH), maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, outW, outH))
+	for y := 0; y < outH; y++ {
+		for x := 0; x < outW; x++ {
+			var r, g, b, a uint32
+			for sy := 0; sy < thumbnailSamples; sy++ {
+				for sx := 0; sx < thumbnailSamples; sx++ {
+					// Position in the upright output, in [0,1)
+					u := (float64(x) + (float64(sx)+0.5)/thumbnailSamples) / float64(outW)
+					v := (float64(y) + (float64(sy)+0.5)/thumbnailSamples) / float64(outH)
+					su, sv := orient(u, v, orientation)
+					px := bounds.Min.X + min(int(su*float64(srcW)), srcW-1)
+					py := bounds.Min.Y + min(int(sv*float64(srcH)), srcH-1)
+					pr, pg, pb, pa := src.At(px, py).RGBA()
+					r, g, b, a = r+pr, g+pg, b+pb, a+pa
+				}
+			}
+			n := uint32(thumbnailSamples * thumbnailSamples)
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// orient maps a relative position in the upright image to the stored image
+// for an EXIF orientation
+func orient(u, v float64, orientation int) (float64, float64) {
+	switch orientation {
+	case 2: // Mirrored horizontally
+		return 1 - u, v
+	case 3: // Rotated 180
+		return 1 - u, 1 - v
+	case 4: // Mirrored vertically
+		return u, 1 - v
+	case 5: // Mirrored along the top-left diagonal
+		return v, u
+	case 6: // Rotated 90 clockwise
+		return v, 1 - u
+	case 7: // Mirrored along the top-right diagonal
+		return 1 - v, 1 - u
+	case 8: // Rotated 90 counterclockwise
+		return 1 - v, u
+	}
+	return u, v
+}
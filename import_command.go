@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"go-server/importers"
+	"go-server/services"
+	"log"
+	"os"
+)
+
+// runImport implements `go-server import [-format name] <file>...`
+func runImport(geoService *services.GeoService, args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "import format, guessed from the file extension when empty")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatalf("Usage: import [-format %v] <file>...", importers.Formats())
+	}
+
+	for _, path := range flags.Args() {
+		fileFormat := *format
+		if fileFormat == "" {
+			fileFormat = importers.FormatFromFilename(path)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", path, err)
+		}
+		report, err := geoService.ImportPOIs(context.Background(), fileFormat, file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Failed to import %s: %v", path, err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	}
+}
@@ -0,0 +1,120 @@
+package importers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-server/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVImporter reads rows with a header line and lat/lon columns. Recognised
+// columns are id, external_id, name, description, type, address, tags,
+// opening_hours, timezone, lat and lon (or latitude/longitude/lng).
+type CSVImporter struct{}
+
+func init() {
+	Register("csv", CSVImporter{})
+}
+
+var csvColumnAliases = map[string]string{
+	"latitude":  "lat",
+	"longitude": "lon",
+	"lng":       "lon",
+}
+
+func (CSVImporter) Import(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := csvColumnAliases[name]; ok {
+			name = alias
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["lat"]; !ok {
+		return nil, fmt.Errorf("CSV is missing a lat column")
+	}
+	if _, ok := columns["lon"]; !ok {
+		return nil, fmt.Errorf("CSV is missing a lon column")
+	}
+
+	var records []Record
+	// The header is line 1
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		record := Record{Row: line}
+		if err != nil {
+			// Only malformed rows can be skipped, read errors repeat forever
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read CSV: %v", err)
+			}
+			record.Err = err
+		} else {
+			record.POI, record.Err = csvRowToPOI(columns, row)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func csvRowToPOI(columns map[string]int, row []string) (poi models.POI, err error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	lat, err := strconv.ParseFloat(field("lat"), 64)
+	if err != nil {
+		return poi, fmt.Errorf("invalid lat %q", field("lat"))
+	}
+	lon, err := strconv.ParseFloat(field("lon"), 64)
+	if err != nil {
+		return poi, fmt.Errorf("invalid lon %q", field("lon"))
+	}
+	if !validCoordinates(lon, lat) {
+		return poi, fmt.Errorf("coordinates out of range")
+	}
+	poi = newPOI(field("name"), lon, lat)
+	if poi.Name == "" {
+		return poi, fmt.Errorf("name is required")
+	}
+	poi.Description = field("description")
+	poi.Address = field("address")
+	poi.OpeningHours = field("opening_hours")
+	poi.Timezone = field("timezone")
+	if poiType := field("type"); poiType != "" {
+		poi.Type = poiType
+	}
+	poi.Tags = splitTags(field("tags"))
+
+	if id := field("external_id"); id != "" {
+		// Already namespaced, e.g. a re-imported CSV export
+		poi.ExternalID = id
+	} else if id := field("id"); id != "" {
+		// Also matched against existing POI IDs, for exports of POIs
+		// created without an external ID
+		poi.ID = id
+		poi.ExternalID = "csv:" + id
+	} else {
+		poi.ExternalID = DeriveExternalID("csv", poi)
+	}
+	return poi, nil
+}
@@ -0,0 +1,60 @@
+package importers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCSVImporter(t *testing.T) {
+	input := "id,name,lat,lon,tags,opening_hours\n" +
+		"a1,Raffles Hotel,1.2949,103.8545,hotel;heritage,24/7\n" +
+		"a2,,1.29,103.85,,\n" +
+		"a3,Bad \"quote,1.29,103.85,,\n" +
+		"a4,Esplanade,1.2895,103.8535,,\n"
+	records, err := CSVImporter{}.Import(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+	first := records[0]
+	if first.Err != nil || first.POI.Name != "Raffles Hotel" || first.POI.ExternalID != "csv:a1" ||
+		first.POI.OpeningHours != "24/7" || len(first.POI.Tags) != 2 {
+		t.Errorf("first record = %+v", first)
+	}
+	if records[1].Err == nil {
+		t.Errorf("row without a name was accepted")
+	}
+	if records[2].Err == nil {
+		t.Errorf("malformed row was accepted")
+	}
+	if records[3].Err != nil || records[3].Row != 5 {
+		t.Errorf("row after a malformed one = %+v", records[3])
+	}
+}
+
+// failingReader returns its data and then the same error on every read
+type failingReader struct {
+	data string
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestCSVImporterReadError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	r := &failingReader{data: "name,lat,lon\nRaffles Hotel,1.2949,103.8545\n", err: readErr}
+	records, err := CSVImporter{}.Import(r)
+	if err == nil || !strings.Contains(err.Error(), readErr.Error()) {
+		t.Fatalf("Import() = %d records, %v, want the read error", len(records), err)
+	}
+}
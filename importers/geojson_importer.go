@@ -0,0 +1,120 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-server/models"
+	"io"
+	"strings"
+)
+
+// GeoJSONImporter reads a FeatureCollection of Point features
+type GeoJSONImporter struct{}
+
+func init() {
+	Register("geojson", GeoJSONImporter{})
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	ID         any            `json:"id"`
+	Geometry   *geoJSONPoint  `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func (GeoJSONImporter) Import(r io.Reader) ([]Record, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("failed to decode GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected a FeatureCollection, got %q", collection.Type)
+	}
+
+	records := make([]Record, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		record := Record{Row: i + 1}
+		record.POI, record.Err = featureToPOI(feature)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func featureToPOI(feature geoJSONFeature) (poi models.POI, err error) {
+	if feature.Geometry == nil || feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+		return poi, fmt.Errorf("geometry must be a Point")
+	}
+	lon, lat := feature.Geometry.Coordinates[0], feature.Geometry.Coordinates[1]
+	if !validCoordinates(lon, lat) {
+		return poi, fmt.Errorf("coordinates out of range")
+	}
+	props := feature.Properties
+	poi = newPOI(stringProperty(props, "name"), lon, lat)
+	if poi.Name == "" {
+		return poi, fmt.Errorf("name property is required")
+	}
+	poi.Description = stringProperty(props, "description")
+	poi.Address = stringProperty(props, "address")
+	poi.OpeningHours = stringProperty(props, "opening_hours")
+	poi.Timezone = stringProperty(props, "timezone")
+	if poiType := stringProperty(props, "type"); poiType != "" {
+		poi.Type = poiType
+	}
+	switch tags := props["tags"].(type) {
+	case []any:
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok && s != "" {
+				poi.Tags = append(poi.Tags, s)
+			}
+		}
+	case string:
+		poi.Tags = splitTags(tags)
+	}
+
+	id := stringProperty(props, "id")
+	if feature.ID != nil {
+		id = fmt.Sprintf("%v", feature.ID)
+	}
+	if externalID := stringProperty(props, "external_id"); externalID != "" {
+		// Already namespaced, e.g. a re-imported GeoJSON export
+		poi.ExternalID = externalID
+	} else if id != "" {
+		// Also matched against existing POI IDs, for exports of POIs
+		// created without an external ID
+		poi.ID = id
+		poi.ExternalID = "geojson:" + id
+	} else {
+		poi.ExternalID = DeriveExternalID("geojson", poi)
+	}
+	return poi, nil
+}
+
+func stringProperty(props map[string]any, key string) string {
+	switch v := props[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// splitTags splits a tag list separated by ";" or ","
+func splitTags(s string) []string {
+	tags := []string{}
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,88 @@
+package importers
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"go-server/models"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Record is a single row read from an import source. Either POI or Err is
+// set. POI.ID holds the source's own ID, if any, which the import matches
+// against existing POIs that have no external ID.
+type Record struct {
+	Row int
+	POI models.POI
+	Err error
+}
+
+// Importer maps a source format onto POIs
+type Importer interface {
+	Import(r io.Reader) ([]Record, error)
+}
+
+var registry = map[string]Importer{}
+
+// Register makes an importer available under the given format name
+func Register(format string, importer Importer) {
+	registry[strings.ToLower(format)] = importer
+}
+
+// Get returns the importer registered for a format
+func Get(format string) (Importer, error) {
+	importer, ok := registry[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported import format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return importer, nil
+}
+
+// Formats lists the registered format names
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for format := range registry {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// FormatFromFilename guesses the import format from a file extension
+func FormatFromFilename(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".geojson"):
+		return "geojson"
+	case strings.HasSuffix(name, ".csv"):
+		return "csv"
+	case strings.HasSuffix(name, ".osm"), strings.HasSuffix(name, ".xml"):
+		return "osm"
+	case strings.HasSuffix(name, ".json"):
+		return "json"
+	}
+	return ""
+}
+
+// newPOI builds a POI with a GeoJSON point location
+func newPOI(name string, lon, lat float64) models.POI {
+	return models.POI{
+		Name:     strings.TrimSpace(name),
+		Type:     "unknown",
+		Tags:     []string{},
+		Location: models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}},
+	}
+}
+
+// DeriveExternalID builds a stable ID for sources that don't carry one, so
+// re-importing the same file updates POIs instead of duplicating them.
+func DeriveExternalID(source string, poi models.POI) string {
+	key := fmt.Sprintf("%s|%.6f|%.6f", strings.ToLower(poi.Name), poi.Location.Coordinates[0], poi.Location.Coordinates[1])
+	return fmt.Sprintf("%s:%x", source, sha1.Sum([]byte(key)))
+}
+
+// validCoordinates reports whether lon/lat are within WGS84 bounds
+func validCoordinates(lon, lat float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
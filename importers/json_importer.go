@@ -0,0 +1,41 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-server/models"
+	"io"
+)
+
+// JSONImporter reads a JSON array of models.POI, the format of data/sg-pois.json
+type JSONImporter struct{}
+
+func init() {
+	Register("json", JSONImporter{})
+}
+
+func (JSONImporter) Import(r io.Reader) ([]Record, error) {
+	var pois []models.POI
+	if err := json.NewDecoder(r).Decode(&pois); err != nil {
+		return nil, fmt.Errorf("failed to decode POI JSON: %v", err)
+	}
+
+	records := make([]Record, 0, len(pois))
+	for i, poi := range pois {
+		record := Record{Row: i + 1}
+		if len(poi.Location.Coordinates) != 2 {
+			record.Err = fmt.Errorf("location must have 2 coordinates")
+		} else {
+			poi.ID = ""
+			if poi.Tags == nil {
+				poi.Tags = []string{}
+			}
+			if poi.ExternalID == "" {
+				poi.ExternalID = DeriveExternalID("json", poi)
+			}
+			record.POI = poi
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
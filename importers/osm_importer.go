@@ -0,0 +1,150 @@
+package importers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go-server/hours"
+	"go-server/models"
+	"io"
+	"strings"
+)
+
+// OSMImporter reads OpenStreetMap XML extracts. Named nodes become POIs at
+// their own position, named ways at the centroid of their nodes.
+type OSMImporter struct{}
+
+func init() {
+	Register("osm", OSMImporter{})
+}
+
+type osmTag struct {
+	Key   string `xml:"k,attr"`
+	Value string `xml:"v,attr"`
+}
+
+type osmNode struct {
+	ID   int64    `xml:"id,attr"`
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Tags []osmTag `xml:"tag"`
+}
+
+type osmWay struct {
+	ID   int64 `xml:"id,attr"`
+	Refs []struct {
+		Ref int64 `xml:"ref,attr"`
+	} `xml:"nd"`
+	Tags []osmTag `xml:"tag"`
+}
+
+// osmTypeKeys are the OSM keys whose value is used as the POI type, in order of preference
+var osmTypeKeys = []string{"amenity", "tourism", "leisure", "shop", "historic"}
+
+func (OSMImporter) Import(r io.Reader) ([]Record, error) {
+	decoder := xml.NewDecoder(r)
+	coords := map[int64][2]float64{}
+	var records []Record
+
+	for row := 1; ; {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode OSM XML: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "node":
+			var node osmNode
+			if err := decoder.DecodeElement(&node, &start); err != nil {
+				return nil, fmt.Errorf("failed to decode OSM node: %v", err)
+			}
+			coords[node.ID] = [2]float64{node.Lon, node.Lat}
+			if osmTagValue(node.Tags, "name") == "" {
+				continue
+			}
+			record := Record{Row: row}
+			record.POI, record.Err = osmToPOI(fmt.Sprintf("node/%d", node.ID), node.Lon, node.Lat, node.Tags)
+			records = append(records, record)
+			row++
+		case "way":
+			var way osmWay
+			if err := decoder.DecodeElement(&way, &start); err != nil {
+				return nil, fmt.Errorf("failed to decode OSM way: %v", err)
+			}
+			if osmTagValue(way.Tags, "name") == "" {
+				continue
+			}
+			record := Record{Row: row}
+			var lon, lat float64
+			var n int
+			for _, nd := range way.Refs {
+				if c, ok := coords[nd.Ref]; ok {
+					lon += c[0]
+					lat += c[1]
+					n++
+				}
+			}
+			if n == 0 {
+				record.Err = fmt.Errorf("way/%d has no resolvable nodes", way.ID)
+			} else {
+				record.POI, record.Err = osmToPOI(fmt.Sprintf("way/%d", way.ID), lon/float64(n), lat/float64(n), way.Tags)
+			}
+			records = append(records, record)
+			row++
+		}
+	}
+	return records, nil
+}
+
+func osmToPOI(osmID string, lon, lat float64, tags []osmTag) (poi models.POI, err error) {
+	if !validCoordinates(lon, lat) {
+		return poi, fmt.Errorf("%s has coordinates out of range", osmID)
+	}
+	poi = newPOI(osmTagValue(tags, "name"), lon, lat)
+	poi.ExternalID = "osm:" + osmID
+	poi.Description = osmTagValue(tags, "description")
+	for _, key := range osmTypeKeys {
+		if value := osmTagValue(tags, key); value != "" {
+			poi.Type = value
+			break
+		}
+	}
+	street := osmTagValue(tags, "addr:street")
+	if houseNumber := osmTagValue(tags, "addr:housenumber"); houseNumber != "" && street != "" {
+		poi.Address = houseNumber + " " + street
+	} else {
+		poi.Address = street
+	}
+	// OSM opening hours often use syntax we don't evaluate, keep only what parses
+	if openingHours := osmTagValue(tags, "opening_hours"); openingHours != "" {
+		if _, err := hours.Parse(openingHours); err == nil {
+			poi.OpeningHours = openingHours
+		}
+	}
+	for _, key := range []string{"cuisine", "diet:halal", "wheelchair", "outdoor_seating"} {
+		value := osmTagValue(tags, key)
+		switch {
+		case value == "":
+		case value == "yes":
+			poi.Tags = append(poi.Tags, strings.TrimPrefix(key, "diet:"))
+		case key == "cuisine":
+			poi.Tags = append(poi.Tags, splitTags(value)...)
+		}
+	}
+	return poi, nil
+}
+
+func osmTagValue(tags []osmTag, key string) string {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return strings.TrimSpace(tag.Value)
+		}
+	}
+	return ""
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"go-server/handlers"
 	"go-server/middleware"
 	"go-server/services"
+	"go-server/storage"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 )
 
 func main() {
@@ -18,7 +22,32 @@ func main() {
 	}
 	// Initialize services and handlers
 	geoService := services.NewGeoService()
+
+	// CLI subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			runImport(geoService, os.Args[2:])
+			return
+		case "migrate-legacy-poi-keys":
+			runMigrateLegacyPOIKeys(geoService)
+			return
+		}
+	}
+
 	poiHandler := handlers.NewPOIHandler(geoService)
+	categoryHandler := handlers.NewCategoryHandler(geoService)
+	cellHandler := handlers.NewCellHandler(geoService)
+	geocodeHandler := handlers.NewGeocodeHandler(geoService)
+
+	// Periodically resync POIs from MongoDB so replicas pick up changes
+	if interval := os.Getenv("POI_SYNC_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid POI_SYNC_INTERVAL value: %v", err)
+		}
+		geoService.StartPOISync(context.Background(), d)
+	}
 
 	// Initialize the user handler with the user service and JWT secret
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -27,11 +56,50 @@ func main() {
 	}
 
 	// Redis
-	userService := services.NewUserService(geoService.RedisClient, jwtSecret)
+	userService := services.NewUserService(geoService, jwtSecret)
 	userHandler := handlers.NewUserHandler(userService, jwtSecret)
 
 	authHandler := handlers.NewAuthHandler(userService, jwtSecret)
 
+	collectionService := services.NewCollectionService(userService, geoService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService)
+
+	checkinRadius := 100.0
+	if radius := os.Getenv("CHECKIN_RADIUS_METERS"); radius != "" {
+		v, err := strconv.ParseFloat(radius, 64)
+		if err != nil || v <= 0 {
+			log.Fatalf("Invalid CHECKIN_RADIUS_METERS %q", radius)
+		}
+		checkinRadius = v
+	}
+	checkinService := services.NewCheckinService(userService, geoService, checkinRadius)
+	checkinHandler := handlers.NewCheckinHandler(checkinService)
+
+	reviewService := services.NewReviewService(userService, geoService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "./media"
+	}
+	mediaBaseURL := os.Getenv("MEDIA_BASE_URL")
+	if mediaBaseURL == "" {
+		mediaBaseURL = "/media"
+	}
+	blobStore, err := storage.NewLocalStore(mediaDir, mediaBaseURL)
+	if err != nil {
+		log.Fatalf("Failed to create media directory: %v", err)
+	}
+	var maxPhotoBytes int64
+	if maxBytes := os.Getenv("MAX_PHOTO_BYTES"); maxBytes != "" {
+		maxPhotoBytes, err = strconv.ParseInt(maxBytes, 10, 64)
+		if err != nil || maxPhotoBytes <= 0 {
+			log.Fatalf("Invalid MAX_PHOTO_BYTES %q", maxBytes)
+		}
+	}
+	photoService := services.NewPhotoService(geoService, blobStore, maxPhotoBytes)
+	photoHandler := handlers.NewPhotoHandler(photoService)
+
 	r := mux.NewRouter()
 
 	// CORS middleware
@@ -53,9 +121,60 @@ func main() {
 	userRouter.HandleFunc("/nearby-friends", userHandler.GetNearbyFriends).Methods("GET", "OPTIONS")
 	userRouter.HandleFunc("/send-friend-request", userHandler.SendFriendRequest).Methods("POST", "OPTIONS")
 	userRouter.HandleFunc("/accept-friend-request", userHandler.AcceptFriendRequest).Methods("POST", "OPTIONS")
+	userRouter.HandleFunc("/favorites", userHandler.GetFavorites).Methods("GET", "OPTIONS")
+	userRouter.HandleFunc("/favorites/{poiID}", userHandler.AddFavorite).Methods("POST", "OPTIONS")
+	userRouter.HandleFunc("/favorites/{poiID}", userHandler.RemoveFavorite).Methods("DELETE", "OPTIONS")
+	userRouter.HandleFunc("/checkins", checkinHandler.GetUserCheckins).Methods("GET", "OPTIONS")
+	userRouter.HandleFunc("/collections", collectionHandler.ListCollections).Methods("GET", "OPTIONS")
+	userRouter.HandleFunc("/collections", collectionHandler.CreateCollection).Methods("POST", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}", collectionHandler.GetCollection).Methods("GET", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}", collectionHandler.UpdateCollection).Methods("PATCH", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}", collectionHandler.DeleteCollection).Methods("DELETE", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}/items", collectionHandler.AddItem).Methods("POST", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}/items/{poiID}", collectionHandler.UpdateItem).Methods("PATCH", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}/items/{poiID}", collectionHandler.RemoveItem).Methods("DELETE", "OPTIONS")
+	userRouter.HandleFunc("/collections/{id}/order", collectionHandler.ReorderItems).Methods("PUT", "OPTIONS")
+
+	// Public collections can be shared by link without logging in
+	r.HandleFunc("/collections/{id}", collectionHandler.GetCollection).Methods("GET", "OPTIONS")
 
 	// POI routes
+	r.HandleFunc("/categories", categoryHandler.GetCategories).Methods("GET", "OPTIONS")
+	r.HandleFunc("/cells/{id}/pois", cellHandler.GetCellPOIs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/geocode", geocodeHandler.Geocode).Methods("GET", "OPTIONS")
+	r.HandleFunc("/geocode/reverse", geocodeHandler.ReverseGeocode).Methods("GET", "OPTIONS")
 	r.HandleFunc("/pois", poiHandler.GetNearbyPOIs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/search", poiHandler.SearchPOIs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/suggest", poiHandler.SuggestPOIs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/within", poiHandler.GetPOIsWithin).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/clusters", poiHandler.GetPOIClusters).Methods("GET", "OPTIONS")
+	r.HandleFunc("/tiles/pois/{z:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.mvt", poiHandler.GetPOITile).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/area", poiHandler.GetPOIsInArea).Methods("POST", "OPTIONS")
+	r.HandleFunc("/pois/export", poiHandler.ExportPOIs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/{id}/reviews", reviewHandler.GetPOIReviews).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pois/{id}", poiHandler.GetPOI).Methods("GET", "OPTIONS")
+
+	// POI routes for signed in users
+	userPOIRouter := r.PathPrefix("/pois").Subrouter()
+	userPOIRouter.Use(middleware.JWTMiddleware(jwtSecret))
+	userPOIRouter.HandleFunc("/{id}/checkin", checkinHandler.CheckIn).Methods("POST", "OPTIONS")
+	userPOIRouter.HandleFunc("/{id}/checkins", checkinHandler.GetPOICheckins).Methods("GET", "OPTIONS")
+	userPOIRouter.HandleFunc("/{id}/review", reviewHandler.SaveReview).Methods("PUT", "OPTIONS")
+	userPOIRouter.HandleFunc("/{id}/review", reviewHandler.DeleteReview).Methods("DELETE", "OPTIONS")
+	userPOIRouter.HandleFunc("/{id}/photos", photoHandler.UploadPhoto).Methods("POST", "OPTIONS")
+	userPOIRouter.HandleFunc("/{id}/photos/{photoID}", photoHandler.DeletePhoto).Methods("DELETE", "OPTIONS")
+
+	// Admin POI routes
+	adminPOIRouter := r.PathPrefix("/pois").Subrouter()
+	adminPOIRouter.Use(middleware.JWTMiddleware(jwtSecret), middleware.AdminMiddleware())
+	adminPOIRouter.HandleFunc("", poiHandler.CreatePOI).Methods("POST", "OPTIONS")
+	adminPOIRouter.HandleFunc("/import", poiHandler.ImportPOIs).Methods("POST", "OPTIONS")
+	adminPOIRouter.HandleFunc("/{id}", poiHandler.UpdatePOI).Methods("PUT", "OPTIONS")
+	adminPOIRouter.HandleFunc("/{id}", poiHandler.PatchPOI).Methods("PATCH", "OPTIONS")
+	adminPOIRouter.HandleFunc("/{id}", poiHandler.DeletePOI).Methods("DELETE", "OPTIONS")
+
+	// Uploaded media, served from the local blob store
+	r.PathPrefix("/media/").Handler(http.StripPrefix("/media/", blobStore.Handler()))
 
 	log.Println("Server starting on :8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
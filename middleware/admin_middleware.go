@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"go-server/utils/errors"
+	"net/http"
+)
+
+// AdminMiddleware only lets through requests whose JWT carries the admin role.
+// It must run after JWTMiddleware.
+func AdminMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("role").(string)
+			if role != "admin" {
+				WriteError(w, errors.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
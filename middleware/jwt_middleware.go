@@ -41,6 +41,9 @@ func JWTMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 			}
 
 			ctx := context.WithValue(r.Context(), "userID", userID)
+			if role, ok := claims["role"].(string); ok {
+				ctx = context.WithValue(ctx, "role", role)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
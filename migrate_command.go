@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"go-server/services"
+	"log"
+)
+
+// runMigrateLegacyPOIKeys implements `go-server migrate-legacy-poi-keys`,
+// which drops the POI hashes older versions wrote under bare POI IDs
+func runMigrateLegacyPOIKeys(geoService *services.GeoService) {
+	dropped, err := geoService.DropLegacyPOIHashes(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to drop legacy POI hashes: %v", err)
+	}
+	log.Printf("Dropped %d legacy POI hashes", dropped)
+}
@@ -0,0 +1,11 @@
+package models
+
+// Category is a node of the POI taxonomy. POI types are category IDs.
+type Category struct {
+	ID     string `json:"id" bson:"_id"`
+	Name   string `json:"name" bson:"name"`
+	Parent string `json:"parent,omitempty" bson:"parent,omitempty"`
+	// Keywords drive automatic classification, matched as whole words in the
+	// POI name and description
+	Keywords []string `json:"keywords,omitempty" bson:"keywords,omitempty"`
+}
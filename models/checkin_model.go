@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type Checkin struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	UserID    string    `json:"user_id" bson:"user_id"` // Public ID of the user
+	Username  string    `json:"username" bson:"username"`
+	POIID     string    `json:"poi_id" bson:"poi_id"`
+	POIName   string    `json:"poi_name" bson:"poi_name"`
+	DistanceM float64   `json:"distance_m" bson:"distance_m"` // Distance from the POI at check-in time
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Collection visibility levels
+const (
+	VisibilityPrivate = "private"
+	VisibilityFriends = "friends"
+	VisibilityPublic  = "public"
+)
+
+type Collection struct {
+	ID          string           `json:"id" bson:"_id,omitempty"`
+	OwnerID     string           `json:"owner_id" bson:"owner_id"` // Public ID of the owner
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description,omitempty" bson:"description,omitempty"`
+	Visibility  string           `json:"visibility" bson:"visibility"`
+	Items       []CollectionItem `json:"items" bson:"items"` // In display order
+	CreatedAt   time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+type CollectionItem struct {
+	POIID   string    `json:"poi_id" bson:"poi_id"`
+	Note    string    `json:"note,omitempty" bson:"note,omitempty"`
+	AddedAt time.Time `json:"added_at" bson:"added_at"`
+}
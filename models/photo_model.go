@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Photo is an image attached to a POI
+type Photo struct {
+	ID           string    `json:"id" bson:"id"`
+	UserID       string    `json:"user_id" bson:"user_id"` // Public ID of the uploader
+	URL          string    `json:"url" bson:"url"`
+	ThumbnailURL string    `json:"thumbnail_url" bson:"thumbnail_url"`
+	ContentType  string    `json:"content_type" bson:"content_type"`
+	Width        int       `json:"width" bson:"width"`
+	Height       int       `json:"height" bson:"height"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+	// Blob store keys, kept for deletion
+	Key          string `json:"-" bson:"key"`
+	ThumbnailKey string `json:"-" bson:"thumbnail_key"`
+}
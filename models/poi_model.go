@@ -2,12 +2,25 @@ package models
 
 type POI struct {
 	ID          string   `json:"id" bson:"_id,omitempty"`
+	ExternalID  string   `json:"external_id,omitempty" bson:"external_id,omitempty"` // Stable ID from the import source
 	Name        string   `json:"name" bson:"name"`
 	Description string   `json:"description" bson:"description"`
 	Type        string   `json:"type" bson:"type"`
 	Location    GeoPoint `json:"location" bson:"location"`
 	Tags        []string `json:"tags" bson:"tags"`
 	Address     string   `json:"address" bson:"address"`
+	// OpenStreetMap opening_hours syntax, e.g. "Mo-Fr 09:00-17:00; Sa 10:00-14:00"
+	OpeningHours string `json:"opening_hours,omitempty" bson:"opening_hours,omitempty"`
+	// IANA time zone the opening hours are in, POI_DEFAULT_TIMEZONE when empty
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	// Review aggregates, maintained by the review service
+	RatingAvg   float64 `json:"rating_avg" bson:"rating_avg,omitempty"`
+	RatingCount int     `json:"rating_count" bson:"rating_count,omitempty"`
+	// Uploaded photos, maintained by the photo service
+	Photos []Photo `json:"photos,omitempty" bson:"photos,omitempty"`
+	// Grid cells of the location, assigned when the POI is stored
+	Geohash string `json:"geohash,omitempty" bson:"geohash,omitempty"`
+	HexCell string `json:"hex_cell,omitempty" bson:"hex_cell,omitempty"`
 }
 
 type GeoPoint struct {
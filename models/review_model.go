@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Review struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	POIID     string    `json:"poi_id" bson:"poi_id"`
+	UserID    string    `json:"user_id" bson:"user_id"` // Public ID of the author
+	Username  string    `json:"username" bson:"username"`
+	Rating    int       `json:"rating" bson:"rating"` // 1 to 5
+	Text      string    `json:"text" bson:"text"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
@@ -6,8 +6,11 @@ type User struct {
 	Username                  string   `json:"username" bson:"username"`
 	Email                     string   `json:"email,omitempty" bson:"email,omitempty"`
 	PasswordHash              string   `json:"password_hash,omitempty" bson:"password_hash,omitempty"`
+	Role                      string   `json:"role,omitempty" bson:"role,omitempty"` // "admin" grants access to admin routes
 	FavoritePOIs              []string `json:"favorite_pois,omitempty" bson:"favorite_pois"`
 	LastLocation              GeoPoint `json:"last_location,omitempty" bson:"last_location,omitempty"`
+	LastGeohash               string   `json:"last_geohash,omitempty" bson:"last_geohash,omitempty"` // Grid cells of LastLocation
+	LastHexCell               string   `json:"last_hex_cell,omitempty" bson:"last_hex_cell,omitempty"`
 	Friends                   []string `json:"friends,omitempty" bson:"friends,omitempty"`
 	PendingFriendRequests     []string `json:"pending_friend_requests,omitempty" bson:"pending_friend_requests,omitempty"`
 	PendingFriendRequestsSent []string `json:"pending_friend_requests_sent,omitempty" bson:"pending_friend_requests_sent,omitempty"`
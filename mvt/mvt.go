@@ -0,0 +1,141 @@
+// Package mvt encodes Mapbox Vector Tiles (version 2.1) holding point
+// features, and maps lon/lat positions to tile coordinates.
+package mvt
+
+import "math"
+
+// DefaultExtent is the number of units across a tile
+const DefaultExtent = 4096
+
+// mercatorMaxLat is the latitude limit of Web Mercator
+const mercatorMaxLat = 85.05112878
+
+// Protobuf field numbers of the vector tile schema
+const (
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+
+	geomPoint = 1
+	cmdMoveTo = 1
+)
+
+// Property is a string attribute of a feature
+type Property struct {
+	Key   string
+	Value string
+}
+
+// Layer is a named set of point features. Build it with NewLayer and AddPoint.
+type Layer struct {
+	name     string
+	extent   int
+	features buffer
+	keys     []string
+	values   []string
+	keyIndex map[string]uint32
+	valIndex map[string]uint32
+}
+
+func NewLayer(name string, extent int) *Layer {
+	return &Layer{name: name, extent: extent, keyIndex: map[string]uint32{}, valIndex: map[string]uint32{}}
+}
+
+// AddPoint adds a point feature at tile coordinates x, y
+func (l *Layer) AddPoint(x, y int, properties []Property) {
+	tags := make([]uint32, 0, 2*len(properties))
+	for _, property := range properties {
+		tags = append(tags, l.key(property.Key), l.value(property.Value))
+	}
+	var feature buffer
+	feature.packedField(featureTags, tags)
+	feature.uintField(featureType, geomPoint)
+	feature.packedField(featureGeometry, []uint32{cmdMoveTo | 1<<3, zigzag(int32(x)), zigzag(int32(y))})
+	l.features.bytesField(layerFeatures, feature)
+}
+
+func (l *Layer) key(key string) uint32 {
+	i, ok := l.keyIndex[key]
+	if !ok {
+		i = uint32(len(l.keys))
+		l.keyIndex[key] = i
+		l.keys = append(l.keys, key)
+	}
+	return i
+}
+
+func (l *Layer) value(value string) uint32 {
+	i, ok := l.valIndex[value]
+	if !ok {
+		i = uint32(len(l.values))
+		l.valIndex[value] = i
+		l.values = append(l.values, value)
+	}
+	return i
+}
+
+func (l *Layer) encode() []byte {
+	var b buffer
+	b.uintField(layerVersion, 2)
+	b.stringField(layerName, l.name)
+	b = append(b, l.features...)
+	for _, key := range l.keys {
+		b.stringField(layerKeys, key)
+	}
+	for _, value := range l.values {
+		var v buffer
+		v.stringField(valueString, value)
+		b.bytesField(layerValues, v)
+	}
+	b.uintField(layerExtent, uint64(l.extent))
+	return b
+}
+
+// Encode writes a tile holding the layers
+func Encode(layers ...*Layer) []byte {
+	var b buffer
+	for _, layer := range layers {
+		b.bytesField(tileLayers, layer.encode())
+	}
+	return b
+}
+
+// TileBounds returns the lon/lat bounds of a tile, grown by buffer tile
+// units on every side and clamped to the Web Mercator world
+func TileBounds(z, x, y, extent, buffer int) (minLon, minLat, maxLon, maxLat float64) {
+	n := float64(int(1) << z)
+	pad := float64(buffer) / float64(extent)
+	minX, maxX := (float64(x)-pad)/n, (float64(x)+1+pad)/n
+	minY, maxY := (float64(y)-pad)/n, (float64(y)+1+pad)/n
+	minLon, maxLon = math.Max(minX*360-180, -180), math.Min(maxX*360-180, 180)
+	minLat, maxLat = math.Max(latitude(maxY), -mercatorMaxLat), math.Min(latitude(minY), mercatorMaxLat)
+	return minLon, minLat, maxLon, maxLat
+}
+
+// Project returns the position of a point in tile units, relative to the
+// top left corner of tile z/x/y
+func Project(lon, lat float64, z, x, y, extent int) (int, int) {
+	lat = math.Max(-mercatorMaxLat, math.Min(mercatorMaxLat, lat))
+	n := float64(int(1) << z)
+	sin := math.Sin(lat * math.Pi / 180)
+	worldX := (lon + 180) / 360
+	worldY := 0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)
+	return int(math.Round((worldX*n - float64(x)) * float64(extent))), int(math.Round((worldY*n - float64(y)) * float64(extent)))
+}
+
+// latitude converts a Web Mercator y in [0, 1] to a latitude
+func latitude(y float64) float64 {
+	y = math.Max(0, math.Min(1, y))
+	return math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+}
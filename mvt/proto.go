@@ -0,0 +1,51 @@
+package mvt
+
+// Just enough of the protobuf wire format to write vector tiles
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+type buffer []byte
+
+func (b *buffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *buffer) key(field int, wireType int) {
+	b.varint(uint64(field<<3 | wireType))
+}
+
+func (b *buffer) uintField(field int, v uint64) {
+	b.key(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *buffer) bytesField(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *buffer) stringField(field int, s string) {
+	b.bytesField(field, []byte(s))
+}
+
+// packedField writes a packed repeated uint32 field
+func (b *buffer) packedField(field int, values []uint32) {
+	var packed buffer
+	for _, v := range values {
+		packed.varint(uint64(v))
+	}
+	b.bytesField(field, packed)
+}
+
+// zigzag maps signed integers to unsigned ones, small magnitudes first
+func zigzag(v int32) uint32 {
+	return uint32((v << 1) ^ (v >> 31))
+}
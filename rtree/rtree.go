@@ -0,0 +1,159 @@
+// Package rtree is an R-tree over points, bulk loaded with the
+// Sort-Tile-Recursive algorithm. Trees are immutable, changes are made by
+// building a new tree.
+package rtree
+
+import (
+	"math"
+	"sort"
+)
+
+// nodeCapacity is the maximum number of entries per node
+const nodeCapacity = 16
+
+// Item is an indexed point
+type Item struct {
+	ID string
+	X  float64
+	Y  float64
+}
+
+// Rect is an axis aligned rectangle, edges included
+type Rect struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+func (r Rect) intersects(o Rect) bool {
+	return r.MinX <= o.MaxX && o.MinX <= r.MaxX && r.MinY <= o.MaxY && o.MinY <= r.MaxY
+}
+
+func (r Rect) contains(x, y float64) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+func (r Rect) extend(o Rect) Rect {
+	return Rect{
+		MinX: math.Min(r.MinX, o.MinX),
+		MinY: math.Min(r.MinY, o.MinY),
+		MaxX: math.Max(r.MaxX, o.MaxX),
+		MaxY: math.Max(r.MaxY, o.MaxY),
+	}
+}
+
+type node struct {
+	bounds   Rect
+	children []*node // Nil for leaves
+	items    []Item
+}
+
+// Tree is a static R-tree
+type Tree struct {
+	root *node
+	size int
+}
+
+// New bulk loads a tree
+func New(items []Item) *Tree {
+	if len(items) == 0 {
+		return &Tree{}
+	}
+	// Leaves first, then each level packs the nodes of the level below
+	sorted := append([]Item(nil), items...)
+	groups := tile(len(sorted),
+		func(i, j int) bool { return sorted[i].X < sorted[j].X },
+		func(i, j int) bool { return sorted[i].Y < sorted[j].Y },
+		func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+	level := make([]*node, 0, len(groups))
+	for _, g := range groups {
+		leaf := &node{items: sorted[g[0]:g[1]]}
+		leaf.bounds = Rect{MinX: leaf.items[0].X, MinY: leaf.items[0].Y, MaxX: leaf.items[0].X, MaxY: leaf.items[0].Y}
+		for _, item := range leaf.items[1:] {
+			leaf.bounds = leaf.bounds.extend(Rect{MinX: item.X, MinY: item.Y, MaxX: item.X, MaxY: item.Y})
+		}
+		level = append(level, leaf)
+	}
+	for len(level) > 1 {
+		nodes := level
+		groups := tile(len(nodes),
+			func(i, j int) bool { return centerX(nodes[i]) < centerX(nodes[j]) },
+			func(i, j int) bool { return centerY(nodes[i]) < centerY(nodes[j]) },
+			func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+		next := make([]*node, 0, len(groups))
+		for _, g := range groups {
+			parent := &node{children: nodes[g[0]:g[1]], bounds: nodes[g[0]].bounds}
+			for _, child := range parent.children[1:] {
+				parent.bounds = parent.bounds.extend(child.bounds)
+			}
+			next = append(next, parent)
+		}
+		level = next
+	}
+	return &Tree{root: level[0], size: len(items)}
+}
+
+// tile sorts n entries into vertical slices by x, each slice by y, and
+// returns the [start, end) ranges of the resulting groups of up to
+// nodeCapacity entries
+func tile(n int, lessX, lessY func(i, j int) bool, swap func(i, j int)) [][2]int {
+	sort.Sort(sorter{n: n, less: lessX, swap: swap})
+	groupCount := (n + nodeCapacity - 1) / nodeCapacity
+	sliceSize := int(math.Ceil(math.Sqrt(float64(groupCount)))) * nodeCapacity
+	var groups [][2]int
+	for start := 0; start < n; start += sliceSize {
+		end := min(start+sliceSize, n)
+		sort.Sort(sorter{
+			n:    end - start,
+			less: func(i, j int) bool { return lessY(start+i, start+j) },
+			swap: func(i, j int) { swap(start+i, start+j) },
+		})
+		for g := start; g < end; g += nodeCapacity {
+			groups = append(groups, [2]int{g, min(g+nodeCapacity, end)})
+		}
+	}
+	return groups
+}
+
+type sorter struct {
+	n    int
+	less func(i, j int) bool
+	swap func(i, j int)
+}
+
+func (s sorter) Len() int           { return s.n }
+func (s sorter) Less(i, j int) bool { return s.less(i, j) }
+func (s sorter) Swap(i, j int)      { s.swap(i, j) }
+
+func centerX(n *node) float64 { return (n.bounds.MinX + n.bounds.MaxX) / 2 }
+func centerY(n *node) float64 { return (n.bounds.MinY + n.bounds.MaxY) / 2 }
+
+// Len returns the number of items
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Search calls fn for every item inside r until fn returns false
+func (t *Tree) Search(r Rect, fn func(Item) bool) {
+	if t.root != nil {
+		t.root.search(r, fn)
+	}
+}
+
+func (n *node) search(r Rect, fn func(Item) bool) bool {
+	if !n.bounds.intersects(r) {
+		return true
+	}
+	if n.children == nil {
+		for _, item := range n.items {
+			if r.contains(item.X, item.Y) && !fn(item) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, child := range n.children {
+		if !child.search(r, fn) {
+			return false
+		}
+	}
+	return true
+}
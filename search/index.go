@@ -0,0 +1,251 @@
+package search
+
+import (
+	"go-server/geometry"
+	"go-server/models"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Field weights, a match in the name counts for more than one in the description
+const (
+	nameWeight        = 3.0
+	tagWeight         = 2.0
+	addressWeight     = 1.5
+	descriptionWeight = 1.0
+)
+
+// Match quality multipliers for the ways a query token can hit a term
+const (
+	exactMatch  = 1.0
+	prefixMatch = 0.7
+	typoMatch   = 0.5
+)
+
+// Hit is a scored search result
+type Hit struct {
+	POI   models.POI
+	Score float64
+}
+
+// Options tune a search
+type Options struct {
+	Limit int
+	// Type restricts hits to a POI type when set
+	Type string
+	// Near boosts hits close to a point when set
+	Near *models.GeoPoint
+}
+
+// Index is an in-memory inverted index over POI name, tags, address and
+// description. It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	pois     map[string]models.POI
+	postings map[string]map[string]float64 // term -> POI ID -> weighted term frequency
+	docTerms map[string][]string           // POI ID -> terms, for removal
+	terms    []string                      // sorted vocabulary, rebuilt lazily
+	dirty    bool
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		pois:     map[string]models.POI{},
+		postings: map[string]map[string]float64{},
+		docTerms: map[string][]string{},
+	}
+}
+
+// Replace rebuilds the index from scratch
+func (idx *Index) Replace(pois []models.POI) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.pois = make(map[string]models.POI, len(pois))
+	idx.postings = map[string]map[string]float64{}
+	idx.docTerms = make(map[string][]string, len(pois))
+	for _, poi := range pois {
+		idx.add(poi)
+	}
+	idx.dirty = true
+}
+
+// Add indexes a POI, replacing any previous version of it
+func (idx *Index) Add(poi models.POI) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(poi.ID)
+	idx.add(poi)
+	idx.dirty = true
+}
+
+// Remove drops a POI from the index
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+	idx.dirty = true
+}
+
+// Len returns the number of indexed POIs
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.pois)
+}
+
+func (idx *Index) add(poi models.POI) {
+	weights := map[string]float64{}
+	for _, token := range Tokenize(poi.Name) {
+		weights[token] += nameWeight
+	}
+	for _, tag := range poi.Tags {
+		for _, token := range Tokenize(tag) {
+			weights[token] += tagWeight
+		}
+	}
+	for _, token := range Tokenize(poi.Address) {
+		weights[token] += addressWeight
+	}
+	for _, token := range Tokenize(poi.Description) {
+		weights[token] += descriptionWeight
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		if idx.postings[term] == nil {
+			idx.postings[term] = map[string]float64{}
+		}
+		idx.postings[term][poi.ID] = weight
+		terms = append(terms, term)
+	}
+	idx.pois[poi.ID] = poi
+	idx.docTerms[poi.ID] = terms
+}
+
+func (idx *Index) remove(id string) {
+	for _, term := range idx.docTerms[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.docTerms, id)
+	delete(idx.pois, id)
+}
+
+// vocabulary returns the sorted term list, rebuilding it after changes
+func (idx *Index) vocabulary() []string {
+	idx.mu.RLock()
+	if !idx.dirty {
+		terms := idx.terms
+		idx.mu.RUnlock()
+		return terms
+	}
+	idx.mu.RUnlock()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.dirty {
+		idx.terms = make([]string, 0, len(idx.postings))
+		for term := range idx.postings {
+			idx.terms = append(idx.terms, term)
+		}
+		sort.Strings(idx.terms)
+		idx.dirty = false
+	}
+	return idx.terms
+}
+
+// expand finds the indexed terms a query token matches, with the quality of each match
+func expand(token string, vocabulary []string) map[string]float64 {
+	matches := map[string]float64{}
+	// Prefix matches, found by binary search on the sorted vocabulary
+	for i := sort.SearchStrings(vocabulary, token); i < len(vocabulary) && strings.HasPrefix(vocabulary[i], token); i++ {
+		if vocabulary[i] == token {
+			matches[token] = exactMatch
+		} else {
+			matches[vocabulary[i]] = prefixMatch
+		}
+	}
+	// Typo tolerance scales with token length, short tokens must be exact
+	maxEdits := 0
+	switch n := len([]rune(token)); {
+	case n >= 8:
+		maxEdits = 2
+	case n >= 4:
+		maxEdits = 1
+	}
+	if maxEdits > 0 {
+		for _, term := range vocabulary {
+			if _, ok := matches[term]; ok {
+				continue
+			}
+			if editDistance(token, term, maxEdits) <= maxEdits {
+				matches[term] = typoMatch
+			}
+		}
+	}
+	return matches
+}
+
+// Search ranks POIs against a free text query. Each query token is matched
+// exactly, as a prefix or with a typo, weighted by field and rarity (IDF).
+// Hits matching more of the query tokens rank higher, and with Near set
+// closer POIs get a boost.
+func (idx *Index) Search(query string, opts Options) []Hit {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return []Hit{}
+	}
+	vocabulary := idx.vocabulary()
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	total := float64(len(idx.pois))
+	scores := map[string]float64{}
+	matched := map[string]int{}
+	for _, token := range tokens {
+		best := map[string]float64{}
+		for term, quality := range expand(token, vocabulary) {
+			postings := idx.postings[term]
+			idf := math.Log(1 + total/float64(len(postings)))
+			for id, weight := range postings {
+				best[id] = math.Max(best[id], quality*weight*idf)
+			}
+		}
+		for id, score := range best {
+			scores[id] += score
+			matched[id]++
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		poi := idx.pois[id]
+		if opts.Type != "" && poi.Type != opts.Type {
+			continue
+		}
+		coverage := float64(matched[id]) / float64(len(tokens))
+		score *= coverage * coverage
+		if opts.Near != nil && len(opts.Near.Coordinates) == 2 && len(poi.Location.Coordinates) == 2 {
+			near, at := opts.Near.Coordinates, poi.Location.Coordinates
+			km := geometry.Distance(near[0], near[1], at[0], at[1]) / 1000
+			score *= 1 + 1/(1+km)
+		}
+		hits = append(hits, Hit{POI: poi, Score: math.Round(score*1000) / 1000})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].POI.ID < hits[j].POI.ID
+	})
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		hits = hits[:opts.Limit]
+	}
+	return hits
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"go-server/geometry"
+	"go-server/models"
+)
+
+// FindPOIsInArea returns up to limit POIs inside a polygon or multipolygon.
+// Candidates come from a bounding box search on the POI store and are then tested
+// exactly against the area, holes included.
+func (s *GeoService) FindPOIsInArea(ctx context.Context, area geometry.MultiPolygon, poiType string, limit int) ([]models.POI, error) {
+	if limit <= 0 {
+		limit = defaultWithinLimit
+	}
+	limit = min(limit, maxWithinLimit)
+
+	bounds := area.Bounds()
+	box := BoundingBox{MinLon: bounds.MinLon, MinLat: bounds.MinLat, MaxLon: bounds.MaxLon, MaxLat: bounds.MaxLat}
+	var results []models.POI
+	err := s.withFallback(ctx, func(store POIStore, fallback bool) error {
+		candidates, _, err := searchBox(ctx, store, box, 0)
+		if err != nil {
+			return err
+		}
+
+		// Test positions before loading any POI data
+		inside := candidates[:0]
+		for _, candidate := range candidates {
+			if area.Contains(candidate.Lon, candidate.Lat) {
+				inside = append(inside, candidate)
+			}
+		}
+		results, err = loadMatchingPOIs(ctx, store, inside, poiType, limit)
+		return err
+	})
+	return results, err
+}
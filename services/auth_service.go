@@ -49,11 +49,13 @@ func (s *UserService) Register(ctx context.Context, username, email, password st
 	}
 
 	// Cache in Redis
-	userJSON, err := json.Marshal(user)
-	if err != nil {
-		return "", errors.Wrap(err, "DB_ERROR", "Failed to marshal user", http.StatusInternalServerError)
+	if s.redisClient != nil {
+		userJSON, err := json.Marshal(user)
+		if err != nil {
+			return "", errors.Wrap(err, "DB_ERROR", "Failed to marshal user", http.StatusInternalServerError)
+		}
+		s.redisClient.Set(ctx, "user:"+userID, userJSON, 24*time.Hour)
 	}
-	s.redisClient.Set(ctx, "user:"+userID, userJSON, 24*time.Hour)
 
 	return user.PublicID, nil
 }
@@ -75,6 +77,7 @@ func (s *UserService) Login(ctx context.Context, username, password string) (str
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userID":   user.PublicID,
 		"username": user.Username,
+		"role":     user.Role,
 		"exp":      time.Now().Add(24 * time.Hour).Unix(),
 	})
 	tokenString, err := token.SignedString([]byte(s.jwtSecret))
@@ -83,11 +86,13 @@ func (s *UserService) Login(ctx context.Context, username, password string) (str
 	}
 
 	// Cache user in Redis
-	userJSON, err := json.Marshal(user)
-	if err != nil {
-		return tokenString, err
+	if s.redisClient != nil {
+		userJSON, err := json.Marshal(user)
+		if err != nil {
+			return tokenString, err
+		}
+		s.redisClient.Set(ctx, "user:"+user.ID, userJSON, 24*time.Hour)
 	}
-	s.redisClient.Set(ctx, "user:"+user.ID, userJSON, 24*time.Hour)
 
 	return tokenString, nil
 }
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/utils/errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	defaultWithinLimit = 200
+	maxWithinLimit     = 1000
+)
+
+// BoundingBox is a lon/lat rectangle. MinLon > MaxLon means the box crosses the antimeridian.
+type BoundingBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// ParseBoundingBox parses "minLon,minLat,maxLon,maxLat"
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, errors.NewAPIError("INVALID_BBOX", "Invalid bounding box", http.StatusBadRequest, "expected minLon,minLat,maxLon,maxLat")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, errors.NewAPIError("INVALID_BBOX", "Invalid bounding box", http.StatusBadRequest, fmt.Sprintf("invalid number %q", part))
+		}
+		values[i] = v
+	}
+	box := BoundingBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if box.MinLat > box.MaxLat || box.MinLat < -90 || box.MaxLat > 90 ||
+		box.MinLon < -180 || box.MinLon > 180 || box.MaxLon < -180 || box.MaxLon > 180 {
+		return BoundingBox{}, errors.NewAPIError("INVALID_BBOX", "Invalid bounding box", http.StatusBadRequest, "coordinates out of range")
+	}
+	if box.empty() {
+		return BoundingBox{}, errors.NewAPIError("INVALID_BBOX", "Invalid bounding box", http.StatusBadRequest, "box has no area")
+	}
+	return box, nil
+}
+
+// Contains reports whether a point lies inside the box, edges included
+func (b BoundingBox) Contains(lon, lat float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// empty reports whether the box has zero width or height
+func (b BoundingBox) empty() bool {
+	return b.MinLat == b.MaxLat || b.MinLon == b.MaxLon
+}
+
+// split returns the box as one or two boxes that don't cross the antimeridian
+func (b BoundingBox) split() []BoundingBox {
+	if b.MinLon <= b.MaxLon {
+		return []BoundingBox{b}
+	}
+	return []BoundingBox{
+		{MinLon: b.MinLon, MinLat: b.MinLat, MaxLon: 180, MaxLat: b.MaxLat},
+		{MinLon: -180, MinLat: b.MinLat, MaxLon: b.MaxLon, MaxLat: b.MaxLat},
+	}
+}
+
+// searchQuery builds a GEOSEARCH BYBOX query covering the box. Redis boxes
+// are measured in meters around a center, so the width is taken at the
+// latitude closest to the equator where a degree of longitude is widest; the
+// results are then trimmed back to the exact box.
+func (b BoundingBox) searchQuery(count int) *redis.GeoSearchLocationQuery {
+	centerLon := (b.MinLon + b.MaxLon) / 2
+	centerLat := (b.MinLat + b.MaxLat) / 2
+	widestLat := 0.0
+	if b.MinLat > 0 {
+		widestLat = b.MinLat
+	} else if b.MaxLat < 0 {
+		widestLat = b.MaxLat
+	}
+	degree := geometry.EarthRadiusMeters * math.Pi / 180
+	width := (b.MaxLon - b.MinLon) * degree * math.Cos(widestLat*math.Pi/180)
+	height := (b.MaxLat - b.MinLat) * degree
+	return &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude: centerLon,
+			Latitude:  centerLat,
+			// Pad slightly so points on the edges aren't lost to rounding or
+			// to Redis measuring on a slightly larger Earth radius
+			BoxWidth:  width*1.001 + 1,
+			BoxHeight: height*1.001 + 1,
+			BoxUnit:   "m",
+			Sort:      "ASC",
+			Count:     count,
+		},
+		WithCoord: true,
+	}
+}
+
+// polygons returns the box as GeoJSON polygons for MongoDB, which reads a
+// polygon larger than a hemisphere as its complement. The box is cut at the
+// antimeridian and the equator and into pieces at most 90° wide, so every
+// piece is well under a hemisphere and touches at most one pole.
+func (b BoundingBox) polygons() []bson.A {
+	var polygons []bson.A
+	for _, part := range b.split() {
+		lats := []float64{part.MinLat, part.MaxLat}
+		if part.MinLat < 0 && part.MaxLat > 0 {
+			lats = []float64{part.MinLat, 0, part.MaxLat}
+		}
+		pieces := max(1, int(math.Ceil((part.MaxLon-part.MinLon)/90)))
+		width := (part.MaxLon - part.MinLon) / float64(pieces)
+		for i := 0; i < pieces; i++ {
+			minLon, maxLon := part.MinLon+float64(i)*width, part.MinLon+float64(i+1)*width
+			if i == pieces-1 {
+				maxLon = part.MaxLon
+			}
+			for j := 0; j+1 < len(lats); j++ {
+				piece := BoundingBox{MinLon: minLon, MinLat: lats[j], MaxLon: maxLon, MaxLat: lats[j+1]}
+				polygons = append(polygons, piece.polygon())
+			}
+		}
+	}
+	return polygons
+}
+
+// polygon returns a box narrower than 180° as a GeoJSON polygon. The
+// horizontal edges are densified so MongoDB's geodesic edges stay close to
+// the parallels, and an edge on a pole collapses to a single vertex.
+func (b BoundingBox) polygon() bson.A {
+	var ring bson.A
+	steps := max(1, int(math.Ceil(b.MaxLon-b.MinLon)))
+	step := (b.MaxLon - b.MinLon) / float64(steps)
+	edge := func(lat float64, from, to, dir int) {
+		if math.Abs(lat) == 90 {
+			ring = append(ring, bson.A{b.MinLon + float64(from)*step, lat})
+			return
+		}
+		for i := from; i != to+dir; i += dir {
+			ring = append(ring, bson.A{b.MinLon + float64(i)*step, lat})
+		}
+	}
+	edge(b.MinLat, 0, steps, 1)
+	edge(b.MaxLat, steps, 0, -1)
+	ring = append(ring, bson.A{b.MinLon, b.MinLat})
+	return bson.A{ring}
+}
+
+// FindPOIsWithin returns up to limit POIs inside a bounding box, nearest to
+// its center first. It reads from the POI store and falls back to MongoDB
+// while Redis is failing.
+func (s *GeoService) FindPOIsWithin(ctx context.Context, box BoundingBox, poiType string, limit int) ([]models.POI, error) {
+	if limit <= 0 {
+		limit = defaultWithinLimit
+	}
+	limit = min(limit, maxWithinLimit)
+
+	var results []models.POI
+	err := s.withFallback(ctx, func(store POIStore, fallback bool) error {
+		var err error
+		results, err = findPOIsWithin(ctx, store, box, poiType, limit)
+		return err
+	})
+	return results, err
+}
+
+// searchBox returns the store hits inside a box, trimmed to its exact
+// bounds. count caps the hits fetched per box part, 0 means no cap. more
+// reports whether a part hit the cap, so the store may hold more.
+func searchBox(ctx context.Context, store POIStore, box BoundingBox, count int) (hits []POIHit, more bool, err error) {
+	for _, part := range box.split() {
+		partHits, err := store.Within(ctx, part, count)
+		if err != nil {
+			return nil, false, err
+		}
+		if count > 0 && len(partHits) >= count {
+			more = true
+		}
+		for _, hit := range partHits {
+			if part.Contains(hit.Lon, hit.Lat) {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits, more, nil
+}
+
+// loadMatchingPOIs loads the POIs behind store hits, keeping up to limit of the given type
+func loadMatchingPOIs(ctx context.Context, store POIStore, hits []POIHit, poiType string, limit int) ([]models.POI, error) {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	pois, err := store.Get(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	results := []models.POI{}
+	for _, poi := range pois {
+		if len(results) == limit {
+			break
+		}
+		if poi == nil || (poiType != "" && poi.Type != poiType) {
+			continue
+		}
+		results = append(results, *poi)
+	}
+	return results, nil
+}
+
+func findPOIsWithin(ctx context.Context, store POIStore, box BoundingBox, poiType string, limit int) ([]models.POI, error) {
+	// With a type filter we need every candidate. Without one the store is
+	// queried on a padded box, so fetch more until enough hits fall inside
+	// the exact box or the store has none left.
+	count := 0
+	if poiType == "" {
+		count = limit * 2
+	}
+	for {
+		hits, more, err := searchBox(ctx, store, box, count)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return loadMatchingPOIs(ctx, store, hits, poiType, limit)
+		}
+		if len(hits) >= limit || !more {
+			return loadMatchingPOIs(ctx, store, hits[:min(len(hits), limit)], poiType, limit)
+		}
+		count *= 2
+	}
+}
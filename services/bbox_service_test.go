@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseBoundingBoxRejectsEmptyBoxes(t *testing.T) {
+	for _, s := range []string{"103.8,1.2,103.8,1.4", "103.8,1.3,103.9,1.3", "10,0,10,0"} {
+		if _, err := ParseBoundingBox(s); err == nil {
+			t.Errorf("ParseBoundingBox(%q) succeeded, want an error", s)
+		}
+	}
+	if _, err := ParseBoundingBox("170,-10,-170,10"); err != nil {
+		t.Errorf("ParseBoundingBox of an antimeridian box: %v", err)
+	}
+}
+
+func TestBoundingBoxPolygons(t *testing.T) {
+	tests := []struct {
+		name   string
+		box    BoundingBox
+		pieces int
+	}{
+		{"small", BoundingBox{MinLon: 103.6, MinLat: 1.2, MaxLon: 104.1, MaxLat: 1.5}, 1},
+		{"crossing the equator", BoundingBox{MinLon: 100, MinLat: -5, MaxLon: 110, MaxLat: 5}, 2},
+		{"antimeridian", BoundingBox{MinLon: 170, MinLat: 10, MaxLon: -170, MaxLat: 20}, 2},
+		{"world", BoundingBox{MinLon: -180, MinLat: -90, MaxLon: 180, MaxLat: 90}, 8},
+		{"z0 tile", BoundingBox{MinLon: -180, MinLat: -85.0511, MaxLon: 180, MaxLat: 85.0511}, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygons := tt.box.polygons()
+			if len(polygons) != tt.pieces {
+				t.Fatalf("got %d polygons, want %d", len(polygons), tt.pieces)
+			}
+			for _, polygon := range polygons {
+				ring := polygon[0].(bson.A)
+				first, last := ring[0].(bson.A), ring[len(ring)-1].(bson.A)
+				if first[0] != last[0] || first[1] != last[1] {
+					t.Errorf("ring isn't closed: %v", ring)
+				}
+				minLon, maxLon, minLat, maxLat := 180.0, -180.0, 90.0, -90.0
+				for i, vertex := range ring {
+					lon, lat := vertex.(bson.A)[0].(float64), vertex.(bson.A)[1].(float64)
+					minLon, maxLon = min(minLon, lon), max(maxLon, lon)
+					minLat, maxLat = min(minLat, lat), max(maxLat, lat)
+					if i > 0 {
+						prev := ring[i-1].(bson.A)
+						if prev[0] == lon && prev[1] == lat {
+							t.Errorf("duplicate vertex %v", vertex)
+						}
+					}
+				}
+				if maxLon-minLon > 90 {
+					t.Errorf("piece is %v° wide", maxLon-minLon)
+				}
+				if minLat < 0 && maxLat > 0 {
+					t.Errorf("piece crosses the equator")
+				}
+			}
+		})
+	}
+}
+
+// paddedStore answers Within on a box padded by a degree, like the Redis
+// store rounding a box out to a square around its center
+type paddedStore struct {
+	*MemoryStore
+}
+
+func (s paddedStore) Within(ctx context.Context, box BoundingBox, count int) ([]POIHit, error) {
+	padded := BoundingBox{MinLon: box.MinLon - 1, MinLat: box.MinLat - 1, MaxLon: box.MaxLon + 1, MaxLat: box.MaxLat + 1}
+	return s.MemoryStore.Within(ctx, padded, count)
+}
+
+func TestFindPOIsWithinFillsTheLimit(t *testing.T) {
+	ctx := context.Background()
+	store := paddedStore{NewMemoryStore()}
+	put := func(id string, lon, lat float64) {
+		poi := models.POI{ID: id, Location: models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}}
+		if err := store.Put(ctx, poi); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Outside POIs are closer to the center than the inside ones, so they
+	// fill the first store pages
+	box := BoundingBox{MinLon: 103.8, MinLat: 1.30, MaxLon: 103.9, MaxLat: 1.31}
+	for i := range 20 {
+		put(fmt.Sprintf("out%d", i), 103.85, 1.315+float64(i)*0.0001)
+	}
+	for i := range 5 {
+		put(fmt.Sprintf("in%d", i), 103.81+float64(i)*0.001, 1.305)
+	}
+
+	pois, err := findPOIsWithin(ctx, store, box, "", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pois) != 4 {
+		t.Fatalf("got %d POIs, want 4", len(pois))
+	}
+	// Nearest to the center first
+	for i, poi := range pois {
+		if want := fmt.Sprintf("in%d", 4-i); poi.ID != want {
+			t.Errorf("POI %d is %s, want %s", i, poi.ID, want)
+		}
+	}
+
+	pois, err = findPOIsWithin(ctx, store, box, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pois) != 5 {
+		t.Errorf("got %d POIs once the store ran out, want 5", len(pois))
+	}
+}
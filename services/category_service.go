@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/models"
+	"go-server/taxonomy"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// unclassifiedType is the type importers give POIs their source doesn't categorize
+const unclassifiedType = "unknown"
+
+// loadTaxonomy reads the category tree from MongoDB, seeding it from
+// data/categories.json when the collection is empty. Changes to the
+// categories collection take effect on restart.
+func loadTaxonomy(ctx context.Context, collection *mongo.Collection) (*taxonomy.Taxonomy, error) {
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		log.Println("No categories found in MongoDB, seeding default taxonomy...")
+		data, err := os.ReadFile("./data/categories.json")
+		if err != nil {
+			return nil, err
+		}
+		var categories []models.Category
+		if err := json.Unmarshal(data, &categories); err != nil {
+			return nil, err
+		}
+		documents := make([]any, len(categories))
+		for i, category := range categories {
+			documents[i] = category
+		}
+		if _, err := collection.InsertMany(ctx, documents); err != nil {
+			return nil, err
+		}
+	}
+
+	// Natural order follows insertion, which the classifier uses to break ties
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var categories []models.Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return taxonomy.New(categories)
+}
+
+// Categories returns the category tree
+func (s *GeoService) Categories() []taxonomy.Node {
+	return s.taxonomy.Tree()
+}
+
+// categoryTypes returns the POI types that fall under a category, including itself
+func (s *GeoService) categoryTypes(category string) (map[string]bool, error) {
+	ids := s.taxonomy.Descendants(category)
+	if len(ids) == 0 {
+		return nil, errors.NewAPIError("INVALID_CATEGORY", "Unknown category", http.StatusBadRequest, category)
+	}
+	types := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		types[id] = true
+	}
+	return types, nil
+}
+
+// classifyPOI assigns a category to a POI that has no type yet
+func (s *GeoService) classifyPOI(poi *models.POI) {
+	if poi.Type != "" && poi.Type != unclassifiedType {
+		return
+	}
+	if category := s.taxonomy.Classify(poi.Name, poi.Description); category != "" {
+		poi.Type = category
+	}
+}
+
+// classifyUnknownPOIs assigns categories to stored POIs without a type, such
+// as those seeded before the taxonomy existed
+func (s *GeoService) classifyUnknownPOIs(ctx context.Context) error {
+	filter := bson.M{"type": bson.M{"$in": bson.A{"", unclassifiedType}}}
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return err
+	}
+
+	classified := 0
+	for _, poi := range pois {
+		s.classifyPOI(&poi)
+		if poi.Type == "" || poi.Type == unclassifiedType {
+			continue
+		}
+		objID, err := poiObjectID(poi.ID)
+		if err != nil {
+			continue
+		}
+		if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"type": poi.Type}}); err != nil {
+			return err
+		}
+		classified++
+	}
+	if classified > 0 {
+		log.Printf("Classified %d of %d untyped POIs", classified, len(pois))
+	}
+	return nil
+}
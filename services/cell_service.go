@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"go-server/geo/cell"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Grids cells assigned to POIs and user location pings
+const (
+	cellGeohashPrecision = 9  // About 5m
+	cellHexResolution    = 15 // About 250m between cell centers
+)
+
+// locationCells returns the geohash and hex cell IDs of a position
+func locationCells(lon, lat float64) (string, string) {
+	return cell.EncodeGeohash(lon, lat, cellGeohashPrecision).ID(), cell.HexAt(lon, lat, cellHexResolution).ID()
+}
+
+// assignCells sets the grid cells of a POI from its location
+func assignCells(poi *models.POI) {
+	if len(poi.Location.Coordinates) == 2 {
+		poi.Geohash, poi.HexCell = locationCells(poi.Location.Coordinates[0], poi.Location.Coordinates[1])
+	}
+}
+
+// assignMissingCells gives stored POIs from before cells existed their cells
+func (s *GeoService) assignMissingCells(ctx context.Context) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"geohash": bson.M{"$exists": false}},
+		bson.M{"hex_cell": bson.M{"$exists": false}},
+	}}
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return err
+	}
+
+	assigned := 0
+	for _, poi := range pois {
+		assignCells(&poi)
+		objID, err := poiObjectID(poi.ID)
+		if err != nil || poi.Geohash == "" {
+			continue
+		}
+		update := bson.M{"$set": bson.M{"geohash": poi.Geohash, "hex_cell": poi.HexCell}}
+		if _, err := s.collection.UpdateOne(ctx, bson.M{"_id": objID}, update); err != nil {
+			return err
+		}
+		assigned++
+	}
+	if assigned > 0 {
+		log.Printf("Assigned grid cells to %d POIs", assigned)
+	}
+	return nil
+}
+
+// FindPOIsInCell returns up to limit POIs inside a geohash or hex cell,
+// nearest to its center first
+func (s *GeoService) FindPOIsInCell(ctx context.Context, id string, poiType string, limit int) (cell.Cell, []models.POI, error) {
+	c, err := cell.Parse(id)
+	if err != nil {
+		return nil, nil, errors.NewAPIError("INVALID_CELL", "Invalid cell", http.StatusBadRequest, err.Error())
+	}
+	if limit <= 0 {
+		limit = defaultWithinLimit
+	}
+	limit = min(limit, maxWithinLimit)
+
+	minLon, minLat, maxLon, maxLat := c.Bounds()
+	box := BoundingBox{MinLon: minLon, MinLat: minLat, MaxLon: maxLon, MaxLat: maxLat}
+	var results []models.POI
+	err = s.withFallback(ctx, func(store POIStore, fallback bool) error {
+		candidates, _, err := searchBox(ctx, store, box, 0)
+		if err != nil {
+			return err
+		}
+		// Hits come nearest to the center of each box part, re-rank them
+		// around the cell center
+		centerLon, centerLat := c.Center()
+		inside := candidates[:0]
+		for _, candidate := range candidates {
+			if c.Contains(candidate.Lon, candidate.Lat) {
+				candidate.Distance = geometry.Distance(centerLon, centerLat, candidate.Lon, candidate.Lat)
+				inside = append(inside, candidate)
+			}
+		}
+		sortHits(inside)
+		// Without a type filter only the nearest limit hits can be returned
+		if poiType == "" && len(inside) > limit {
+			inside = inside[:limit]
+		}
+		results, err = loadMatchingPOIs(ctx, store, inside, poiType, limit)
+		return err
+	})
+	return c, results, err
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultCheckinLimit = 50
+	maxCheckinLimit     = 200
+	// checkinCooldown stops users from checking in at the same POI repeatedly
+	checkinCooldown = 30 * time.Minute
+)
+
+type CheckinService struct {
+	collection   *mongo.Collection
+	cooldowns    *mongo.Collection // End of the cooldown per user and POI
+	userService  *UserService
+	geoService   *GeoService
+	radiusMeters float64 // How close the last location ping must be to the POI
+}
+
+func NewCheckinService(userService *UserService, geoService *GeoService, radiusMeters float64) *CheckinService {
+	collection := userService.collection.Database().Collection("checkins")
+
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "poi_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexModels); err != nil {
+		log.Printf("Failed to create indexes on checkins: %v", err)
+	}
+	cooldowns := userService.collection.Database().Collection("checkin_cooldowns")
+	// Expired cooldowns are dropped by MongoDB, claimCooldown doesn't rely on it
+	expiry := mongo.IndexModel{Keys: bson.D{{Key: "until", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)}
+	if _, err := cooldowns.Indexes().CreateOne(context.Background(), expiry); err != nil {
+		log.Printf("Failed to create index on checkin_cooldowns: %v", err)
+	}
+
+	return &CheckinService{
+		collection:   collection,
+		cooldowns:    cooldowns,
+		userService:  userService,
+		geoService:   geoService,
+		radiusMeters: radiusMeters,
+	}
+}
+
+// CheckIn records a visit of the current user to a POI. The user's location
+// ping in users:geo must be recent and within the check-in radius of the
+// POI's position in pois:geo.
+func (s *CheckinService) CheckIn(ctx context.Context, poiID string) (models.Checkin, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return models.Checkin{}, errors.ErrUnauthorized
+	}
+	user, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		return models.Checkin{}, errors.ErrNotFound
+	}
+
+	userLon, userLat, ok, err := s.userService.recentLocation(ctx, userID)
+	if err != nil {
+		return models.Checkin{}, errors.Wrap(err, "REDIS_ERROR", "Failed to get user location", http.StatusInternalServerError)
+	}
+	if !ok {
+		return models.Checkin{}, errors.NewAPIError("NO_RECENT_LOCATION", "Ping your location before checking in", http.StatusConflict)
+	}
+	poi, err := s.geoService.GetPOI(ctx, poiID)
+	if err != nil {
+		return models.Checkin{}, err
+	}
+	if len(poi.Location.Coordinates) < 2 {
+		return models.Checkin{}, errors.ErrNotFound
+	}
+	distance, inRange, err := s.distanceToPOI(ctx, userLon, userLat, poiID)
+	if err != nil {
+		return models.Checkin{}, err
+	}
+	if !inRange {
+		// Only for the message, pois:geo said the POI isn't in range
+		distance = geometry.Distance(userLon, userLat, poi.Location.Coordinates[0], poi.Location.Coordinates[1])
+		return models.Checkin{}, errors.NewAPIError("TOO_FAR", "Too far from the POI to check in", http.StatusConflict,
+			"must be within "+formatMeters(s.radiusMeters)+", currently "+formatMeters(distance))
+	}
+
+	// MongoDB keeps milliseconds, releasing the cooldown matches on it
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	claimed, err := s.claimCooldown(ctx, userID, poiID, now)
+	if err != nil {
+		return models.Checkin{}, errors.Wrap(err, "DB_ERROR", "Failed to check recent check-ins", http.StatusInternalServerError)
+	}
+	if !claimed {
+		return models.Checkin{}, errors.NewAPIError("CONFLICT", "Already checked in here recently", http.StatusConflict)
+	}
+
+	checkin := models.Checkin{
+		UserID:    userID,
+		Username:  user.Username,
+		POIID:     poiID,
+		POIName:   poi.Name,
+		DistanceM: math.Round(distance*10) / 10,
+		CreatedAt: now,
+	}
+	result, err := s.collection.InsertOne(ctx, checkin)
+	if err != nil {
+		// Let the user retry instead of waiting out a cooldown for nothing
+		if _, releaseErr := s.cooldowns.DeleteOne(ctx, bson.M{"_id": cooldownID(userID, poiID), "until": now.Add(checkinCooldown)}); releaseErr != nil {
+			log.Printf("Failed to release check-in cooldown: %v", releaseErr)
+		}
+		return models.Checkin{}, errors.Wrap(err, "DB_ERROR", "Failed to save check-in", http.StatusInternalServerError)
+	}
+	checkin.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	log.Printf("User %s checked in at %s (%.0fm away)", user.Username, poi.Name, distance)
+	return checkin, nil
+}
+
+// distanceToPOI finds a POI among the POI store hits within the check-in
+// radius of a point, reading pois:geo with the Redis store. inRange is false
+// when the POI isn't among them.
+func (s *CheckinService) distanceToPOI(ctx context.Context, lon, lat float64, poiID string) (distance float64, inRange bool, err error) {
+	err = s.geoService.withFallback(ctx, func(store POIStore, fallback bool) error {
+		hits, err := store.Nearby(ctx, lon, lat, s.radiusMeters)
+		if err != nil {
+			return err
+		}
+		distance, inRange = 0, false
+		for _, hit := range hits {
+			if hit.ID == poiID {
+				distance, inRange = hit.Distance, true
+				break
+			}
+		}
+		return nil
+	})
+	return distance, inRange, err
+}
+
+// claimCooldown starts the cooldown of a user at a POI, or reports false
+// while an earlier one is still running. The filter only matches an expired
+// cooldown, so a running one makes the upsert insert a duplicate _id and
+// concurrent check-ins can't both get through.
+func (s *CheckinService) claimCooldown(ctx context.Context, userID, poiID string, now time.Time) (bool, error) {
+	filter := bson.M{"_id": cooldownID(userID, poiID), "until": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"until": now.Add(checkinCooldown)}}
+	_, err := s.cooldowns.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func cooldownID(userID, poiID string) string {
+	return userID + ":" + poiID
+}
+
+// GetUserCheckins returns a page of the current user's check-ins, newest
+// first, and the cursor of the next page ("" on the last page)
+func (s *CheckinService) GetUserCheckins(ctx context.Context, cursor string, limit int) ([]models.Checkin, string, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return nil, "", errors.ErrUnauthorized
+	}
+	return s.findCheckins(ctx, bson.M{"user_id": userID}, cursor, limit)
+}
+
+// GetPOICheckins returns a page of the check-ins at a POI by the current user
+// and their friends, newest first, and the cursor of the next page
+func (s *CheckinService) GetPOICheckins(ctx context.Context, poiID string, cursor string, limit int) ([]models.Checkin, string, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return nil, "", errors.ErrUnauthorized
+	}
+	user, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		return nil, "", errors.ErrNotFound
+	}
+	visible := append([]string{userID}, user.Friends...)
+	return s.findCheckins(ctx, bson.M{"poi_id": poiID, "user_id": bson.M{"$in": visible}}, cursor, limit)
+}
+
+// findCheckins returns a page of check-ins, ties on created_at are broken by
+// ID so no check-in is skipped between pages
+func (s *CheckinService) findCheckins(ctx context.Context, filter bson.M, cursor string, limit int) ([]models.Checkin, string, error) {
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = defaultCheckinLimit
+	}
+	limit = min(limit, maxCheckinLimit)
+	if after != nil {
+		lastID, err := primitive.ObjectIDFromHex(after.LastID)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.Time}},
+			bson.M{"created_at": after.Time, "_id": bson.M{"$lt": lastID}},
+		}
+	}
+
+	// One extra check-in tells whether there is a next page
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+	dbCursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "DB_ERROR", "Failed to get check-ins", http.StatusInternalServerError)
+	}
+	defer dbCursor.Close(ctx)
+	checkins := []models.Checkin{}
+	if err := dbCursor.All(ctx, &checkins); err != nil {
+		return nil, "", errors.Wrap(err, "DB_ERROR", "Failed to decode check-ins", http.StatusInternalServerError)
+	}
+	nextCursor := ""
+	if len(checkins) > limit {
+		checkins = checkins[:limit]
+		last := checkins[limit-1]
+		nextCursor = encodeTimeCursor(timeCursor{Time: last.CreatedAt, LastID: last.ID})
+	}
+	return checkins, nextCursor, nil
+}
+
+func formatMeters(m float64) string {
+	return strconv.FormatFloat(math.Round(m), 'f', 0, 64) + "m"
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-server/cluster"
+	"go-server/models"
+	"go-server/utils/errors"
+	"hash/fnv"
+	"net/http"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindClusters returns the POI clusters whose centroid lies inside a box at a
+// map zoom level. Clusters are precomputed for every zoom level on sync and
+// rebuilt on the first request after a POI edit.
+func (s *GeoService) FindClusters(ctx context.Context, box BoundingBox, zoom int) ([]cluster.Cluster, error) {
+	index, err := s.clusterIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusters := []cluster.Cluster{}
+	for _, part := range box.split() {
+		clusters = append(clusters, index.Within(part.MinLon, part.MinLat, part.MaxLon, part.MaxLat, zoom)...)
+	}
+	return clusters, nil
+}
+
+// clusterIndex returns the current clusters, rebuilding them from MongoDB
+// when they were invalidated
+func (s *GeoService) clusterIndex(ctx context.Context) (*cluster.Index, error) {
+	s.clustersMu.Lock()
+	defer s.clustersMu.Unlock()
+	if s.clusters != nil {
+		return s.clusters, nil
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "location": 1, "type": 1})
+	cursor, err := s.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to load POIs", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to decode POIs", http.StatusInternalServerError)
+	}
+	s.clusters = buildClusters(pois)
+	return s.clusters, nil
+}
+
+func (s *GeoService) hasClusters() bool {
+	s.clustersMu.Lock()
+	defer s.clustersMu.Unlock()
+	return s.clusters != nil
+}
+
+// swapPlacement records the placement fingerprint of the synced POIs and
+// reports whether it differs from the previous sync
+func (s *GeoService) swapPlacement(fingerprint uint64) bool {
+	s.clustersMu.Lock()
+	defer s.clustersMu.Unlock()
+	changed := fingerprint != s.placement
+	s.placement = fingerprint
+	return changed
+}
+
+// placementFingerprint hashes the IDs, types and positions of POIs,
+// independently of their order
+func placementFingerprint(pois []models.POI) uint64 {
+	var sum uint64
+	for _, poi := range pois {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%s|%s|%v", poi.ID, poi.Type, poi.Location.Coordinates)
+		sum += h.Sum64()
+	}
+	return sum
+}
+
+// setClusters replaces the clusters, nil invalidates them
+func (s *GeoService) setClusters(index *cluster.Index) {
+	s.clustersMu.Lock()
+	s.clusters = index
+	s.clustersMu.Unlock()
+}
+
+func buildClusters(pois []models.POI) *cluster.Index {
+	points := make([]cluster.Point, 0, len(pois))
+	for _, poi := range pois {
+		if len(poi.Location.Coordinates) == 2 {
+			points = append(points, cluster.Point{ID: poi.ID, Lon: poi.Location.Coordinates[0], Lat: poi.Location.Coordinates[1], Type: poi.Type})
+		}
+	}
+	return cluster.New(points)
+}
+
+// samePlacement reports whether two versions of a POI cluster the same way
+func samePlacement(a, b models.POI) bool {
+	return a.Type == b.Type && slices.Equal(a.Location.Coordinates, b.Location.Coordinates)
+}
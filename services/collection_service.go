@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxCollectionNameLength = 100
+	maxCollectionItems      = 500
+)
+
+type CollectionService struct {
+	collection  *mongo.Collection
+	userService *UserService
+	geoService  *GeoService
+}
+
+// CollectionInput holds the editable fields of a collection, nil fields are left untouched on update
+type CollectionInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Visibility  *string `json:"visibility"`
+}
+
+// CollectionItemView is a collection item with its POI resolved. POI is nil
+// when the POI has been deleted since it was added.
+type CollectionItemView struct {
+	models.CollectionItem
+	POI *models.POI `json:"poi"`
+}
+
+// CollectionView is a collection as shown to a viewer
+type CollectionView struct {
+	ID          string               `json:"id"`
+	OwnerID     string               `json:"owner_id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Visibility  string               `json:"visibility"`
+	Items       []CollectionItemView `json:"items"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+func NewCollectionService(userService *UserService, geoService *GeoService) *CollectionService {
+	collection := userService.collection.Database().Collection("collections")
+
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "updated_at", Value: -1}},
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		log.Printf("Failed to create index on collections: %v", err)
+	}
+
+	return &CollectionService{
+		collection:  collection,
+		userService: userService,
+		geoService:  geoService,
+	}
+}
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case models.VisibilityPrivate, models.VisibilityFriends, models.VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// applyCollectionInput copies the set fields of input onto c and validates the result
+func applyCollectionInput(c *models.Collection, input CollectionInput) error {
+	if input.Name != nil {
+		c.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		c.Description = *input.Description
+	}
+	if input.Visibility != nil {
+		c.Visibility = *input.Visibility
+	}
+	if c.Name == "" || len(c.Name) > maxCollectionNameLength {
+		return errors.NewAPIError("INVALID_COLLECTION", "Invalid collection", http.StatusBadRequest, "name must be 1-100 characters")
+	}
+	if !validVisibility(c.Visibility) {
+		return errors.NewAPIError("INVALID_COLLECTION", "Invalid collection", http.StatusBadRequest, "visibility must be private, friends or public")
+	}
+	return nil
+}
+
+// ownerFilter matches a collection owned by the current user
+func ownerFilter(ctx context.Context, collectionID string) (bson.M, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return nil, errors.ErrUnauthorized
+	}
+	objID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return nil, errors.ErrNotFound
+	}
+	return bson.M{"_id": objID, "owner_id": userID}, nil
+}
+
+// CreateCollection creates an empty collection for the current user
+func (s *CollectionService) CreateCollection(ctx context.Context, input CollectionInput) (models.Collection, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return models.Collection{}, errors.ErrUnauthorized
+	}
+	now := time.Now().UTC()
+	c := models.Collection{
+		OwnerID:    userID,
+		Visibility: models.VisibilityPrivate,
+		Items:      []models.CollectionItem{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := applyCollectionInput(&c, input); err != nil {
+		return models.Collection{}, err
+	}
+
+	result, err := s.collection.InsertOne(ctx, c)
+	if err != nil {
+		return models.Collection{}, errors.Wrap(err, "DB_ERROR", "Failed to create collection", http.StatusInternalServerError)
+	}
+	c.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return c, nil
+}
+
+// UpdateCollection changes the name, description or visibility of one of the current user's collections
+func (s *CollectionService) UpdateCollection(ctx context.Context, collectionID string, input CollectionInput) (models.Collection, error) {
+	filter, err := ownerFilter(ctx, collectionID)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	var c models.Collection
+	if err := s.collection.FindOne(ctx, filter).Decode(&c); err != nil {
+		return models.Collection{}, notFoundOr(err, "Failed to get collection")
+	}
+	if err := applyCollectionInput(&c, input); err != nil {
+		return models.Collection{}, err
+	}
+	c.UpdatedAt = time.Now().UTC()
+
+	update := bson.M{"$set": bson.M{
+		"name":        c.Name,
+		"description": c.Description,
+		"visibility":  c.Visibility,
+		"updated_at":  c.UpdatedAt,
+	}}
+	if _, err := s.collection.UpdateOne(ctx, filter, update); err != nil {
+		return models.Collection{}, errors.Wrap(err, "DB_ERROR", "Failed to update collection", http.StatusInternalServerError)
+	}
+	return c, nil
+}
+
+// DeleteCollection deletes one of the current user's collections
+func (s *CollectionService) DeleteCollection(ctx context.Context, collectionID string) error {
+	filter, err := ownerFilter(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	result, err := s.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to delete collection", http.StatusInternalServerError)
+	}
+	if result.DeletedCount == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// ListCollections lists the collections of ownerID that the current user may see,
+// newest first. An empty ownerID lists the current user's own collections.
+func (s *CollectionService) ListCollections(ctx context.Context, ownerID string) ([]models.Collection, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return nil, errors.ErrUnauthorized
+	}
+	if ownerID == "" {
+		ownerID = userID
+	}
+
+	filter := bson.M{"owner_id": ownerID}
+	if ownerID != userID {
+		visible := []string{models.VisibilityPublic}
+		if s.areFriends(ctx, ownerID, userID) {
+			visible = append(visible, models.VisibilityFriends)
+		}
+		filter["visibility"] = bson.M{"$in": visible}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to list collections", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	collections := []models.Collection{}
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to decode collections", http.StatusInternalServerError)
+	}
+	return collections, nil
+}
+
+// GetCollection returns a collection with its POIs if the viewer may see it.
+// viewerID may be empty for anonymous viewers, who only see public collections.
+// Collections the viewer may not see are reported as not found.
+func (s *CollectionService) GetCollection(ctx context.Context, collectionID, viewerID string) (CollectionView, error) {
+	objID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return CollectionView{}, errors.ErrNotFound
+	}
+	var c models.Collection
+	if err := s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&c); err != nil {
+		return CollectionView{}, notFoundOr(err, "Failed to get collection")
+	}
+	if !s.canView(ctx, c, viewerID) {
+		return CollectionView{}, errors.ErrNotFound
+	}
+
+	ids := make([]string, len(c.Items))
+	for i, item := range c.Items {
+		ids[i] = item.POIID
+	}
+	pois, err := s.geoService.GetPOIsByID(ctx, ids)
+	if err != nil {
+		return CollectionView{}, err
+	}
+	byID := make(map[string]*models.POI, len(pois))
+	for i := range pois {
+		byID[pois[i].ID] = &pois[i]
+	}
+
+	view := CollectionView{
+		ID:          c.ID,
+		OwnerID:     c.OwnerID,
+		Name:        c.Name,
+		Description: c.Description,
+		Visibility:  c.Visibility,
+		Items:       make([]CollectionItemView, len(c.Items)),
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+	for i, item := range c.Items {
+		view.Items[i] = CollectionItemView{CollectionItem: item, POI: byID[item.POIID]}
+	}
+	return view, nil
+}
+
+// AddItem adds a POI to one of the current user's collections. position is
+// the 0-based index to insert at, a negative position appends.
+func (s *CollectionService) AddItem(ctx context.Context, collectionID, poiID, note string, position int) error {
+	filter, err := ownerFilter(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.geoService.GetPOI(ctx, poiID); err != nil {
+		return err
+	}
+
+	item := models.CollectionItem{POIID: poiID, Note: note, AddedAt: time.Now().UTC()}
+	push := bson.M{"$each": bson.A{item}}
+	if position >= 0 {
+		push["$position"] = position
+	}
+	// Only match when the POI isn't in the collection yet and there's room for it
+	filter["items.poi_id"] = bson.M{"$ne": poiID}
+	filter["items."+strconv.Itoa(maxCollectionItems-1)] = bson.M{"$exists": false}
+	update := bson.M{
+		"$push": bson.M{"items": push},
+		"$set":  bson.M{"updated_at": item.AddedAt},
+	}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to add collection item", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		// Tell a missing collection apart from a duplicate or full one
+		delete(filter, "items.poi_id")
+		delete(filter, "items."+strconv.Itoa(maxCollectionItems-1))
+		if count, _ := s.collection.CountDocuments(ctx, filter); count == 0 {
+			return errors.ErrNotFound
+		}
+		return errors.NewAPIError("CONFLICT", "POI is already in the collection or the collection is full", http.StatusConflict)
+	}
+	return nil
+}
+
+// UpdateItemNote changes the note on a collection item
+func (s *CollectionService) UpdateItemNote(ctx context.Context, collectionID, poiID, note string) error {
+	filter, err := ownerFilter(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	filter["items.poi_id"] = poiID
+	update := bson.M{"$set": bson.M{"items.$.note": note, "updated_at": time.Now().UTC()}}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to update collection item", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// RemoveItem removes a POI from one of the current user's collections
+func (s *CollectionService) RemoveItem(ctx context.Context, collectionID, poiID string) error {
+	filter, err := ownerFilter(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	filter["items.poi_id"] = poiID
+	update := bson.M{
+		"$pull": bson.M{"items": bson.M{"poi_id": poiID}},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to remove collection item", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// ReorderItems reorders a collection. poiIDs must list every item exactly once.
+func (s *CollectionService) ReorderItems(ctx context.Context, collectionID string, poiIDs []string) error {
+	filter, err := ownerFilter(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	var c models.Collection
+	if err := s.collection.FindOne(ctx, filter).Decode(&c); err != nil {
+		return notFoundOr(err, "Failed to get collection")
+	}
+
+	byID := make(map[string]models.CollectionItem, len(c.Items))
+	for _, item := range c.Items {
+		byID[item.POIID] = item
+	}
+	if len(poiIDs) != len(c.Items) {
+		return errors.NewAPIError("INVALID_ORDER", "Invalid item order", http.StatusBadRequest, "poi_ids must list every item exactly once")
+	}
+	items := make([]models.CollectionItem, 0, len(poiIDs))
+	for _, id := range poiIDs {
+		item, ok := byID[id]
+		if !ok {
+			return errors.NewAPIError("INVALID_ORDER", "Invalid item order", http.StatusBadRequest, "poi_ids must list every item exactly once")
+		}
+		delete(byID, id)
+		items = append(items, item)
+	}
+
+	// Guard against the items changing between the read and the write
+	filter["updated_at"] = c.UpdatedAt
+	update := bson.M{"$set": bson.M{"items": items, "updated_at": time.Now().UTC()}}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to reorder collection", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewAPIError("CONFLICT", "Collection changed while reordering, try again", http.StatusConflict)
+	}
+	return nil
+}
+
+// canView applies the collection's visibility to a viewer
+func (s *CollectionService) canView(ctx context.Context, c models.Collection, viewerID string) bool {
+	switch {
+	case c.Visibility == models.VisibilityPublic:
+		return true
+	case viewerID == "":
+		return false
+	case c.OwnerID == viewerID:
+		return true
+	case c.Visibility == models.VisibilityFriends:
+		return s.areFriends(ctx, c.OwnerID, viewerID)
+	}
+	return false
+}
+
+// areFriends reports whether userID is on ownerID's friends list
+func (s *CollectionService) areFriends(ctx context.Context, ownerID, userID string) bool {
+	owner, err := s.userService.GetUser(ctx, ownerID)
+	if err != nil {
+		return false
+	}
+	for _, friendID := range owner.Friends {
+		if friendID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// notFoundOr maps a missing document to ErrNotFound and wraps anything else
+func notFoundOr(err error, message string) error {
+	if err == mongo.ErrNoDocuments {
+		return errors.ErrNotFound
+	}
+	return errors.Wrap(err, "DB_ERROR", message, http.StatusInternalServerError)
+}
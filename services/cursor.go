@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"go-server/utils/errors"
+	"net/http"
+	"time"
+)
+
+// pageCursor marks the last item of a page of distance-sorted results, or
+// rating-sorted ones when Rating is set
+type pageCursor struct {
+	Rating   float64 `json:"r,omitempty"`
+	Distance float64 `json:"d"`
+	LastID   string  `json:"id"`
+}
+
+// ErrInvalidCursor is returned when a client sends a cursor we didn't issue
+var ErrInvalidCursor = errors.NewAPIError("INVALID_CURSOR", "Invalid pagination cursor", http.StatusBadRequest)
+
+// encodeCursor turns a cursor into the opaque string handed to clients
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor string, an empty string means the first page
+func decodeCursor(s string) (*pageCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.LastID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// after reports whether an item sorts after the cursor position
+func (c *pageCursor) after(distance float64, id string) bool {
+	if c == nil {
+		return true
+	}
+	return distance > c.Distance || (distance == c.Distance && id > c.LastID)
+}
+
+// afterRated reports whether an item sorts after the cursor position when
+// results are ordered by rating, best first, then by distance
+func (c *pageCursor) afterRated(rating, distance float64, id string) bool {
+	if c == nil {
+		return true
+	}
+	return rating < c.Rating || (rating == c.Rating && c.after(distance, id))
+}
+
+// timeCursor marks the last item of a page of results sorted newest first,
+// with ties on the timestamp broken by ID
+type timeCursor struct {
+	Time   time.Time `json:"t"`
+	LastID string    `json:"id"`
+}
+
+// encodeTimeCursor turns a time cursor into the opaque string handed to clients
+func encodeTimeCursor(c timeCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTimeCursor parses a time cursor string, an empty string means the first page
+func decodeTimeCursor(s string) (*timeCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c timeCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.LastID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
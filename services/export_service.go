@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/utils/errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// StreamPOIs calls fn for every POI matching the filters, reading straight
+// from a MongoDB cursor so large exports are never held in memory. A radius
+// <= 0 disables the location filter and an empty poiType matches all types.
+func (s *GeoService) StreamPOIs(ctx context.Context, lat, lon, radius float64, poiType string, fn func(models.POI) error) error {
+	filter := bson.M{}
+	if radius > 0 {
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return errors.ErrInvalidInput
+		}
+		filter["location"] = bson.M{
+			"$geoWithin": bson.M{
+				"$centerSphere": bson.A{bson.A{lon, lat}, radius / geometry.EarthRadiusMeters},
+			},
+		}
+	}
+	if poiType != "" {
+		filter["type"] = poiType
+	}
+
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to query POIs", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var poi models.POI
+		if err := cursor.Decode(&poi); err != nil {
+			return err
+		}
+		if err := fn(poi); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FavoritePOI is a favorited POI, with its direction when the caller sent a location
+type FavoritePOI struct {
+	models.POI
+	*Direction
+}
+
+// AddFavoritePOI adds an existing POI to the current user's favorites
+func (s *UserService) AddFavoritePOI(ctx context.Context, poiID string) error {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return errors.ErrUnauthorized
+	}
+	if _, err := s.geoService.GetPOI(ctx, poiID); err != nil {
+		return err
+	}
+
+	update := bson.M{"$addToSet": bson.M{"favorite_pois": poiID}}
+	result, err := s.collection.UpdateOne(ctx, bson.M{"public_id": userID}, update)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to add favorite", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		return errors.ErrNotFound
+	}
+	s.invalidateUser(ctx, userID)
+	return nil
+}
+
+// RemoveFavoritePOI removes a POI from the current user's favorites. The POI
+// doesn't have to exist anymore, so favorites of deleted POIs can be cleaned up.
+func (s *UserService) RemoveFavoritePOI(ctx context.Context, poiID string) error {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return errors.ErrUnauthorized
+	}
+
+	update := bson.M{"$pull": bson.M{"favorite_pois": poiID}}
+	result, err := s.collection.UpdateOne(ctx, bson.M{"public_id": userID}, update)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to remove favorite", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		return errors.ErrNotFound
+	}
+	s.invalidateUser(ctx, userID)
+	return nil
+}
+
+// GetFavoritePOIs returns the current user's favorite POIs in the order they
+// were added, or sorted by distance when near is set
+func (s *UserService) GetFavoritePOIs(ctx context.Context, near *models.GeoPoint, units DistanceUnit) ([]FavoritePOI, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return nil, errors.ErrUnauthorized
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrNotFound
+	}
+
+	pois, err := s.geoService.GetPOIsByID(ctx, user.FavoritePOIs)
+	if err != nil {
+		return nil, err
+	}
+	favorites := make([]FavoritePOI, len(pois))
+	for i, poi := range pois {
+		favorites[i] = FavoritePOI{POI: poi}
+		if near != nil && len(poi.Location.Coordinates) == 2 {
+			fromLon, fromLat := near.Coordinates[0], near.Coordinates[1]
+			lon, lat := poi.Location.Coordinates[0], poi.Location.Coordinates[1]
+			direction := newDirection(fromLon, fromLat, lon, lat, geometry.Distance(fromLon, fromLat, lon, lat), units)
+			favorites[i].Direction = &direction
+		}
+	}
+	if near != nil {
+		sort.SliceStable(favorites, func(i, j int) bool {
+			if favorites[i].Direction == nil || favorites[j].Direction == nil {
+				return favorites[j].Direction == nil && favorites[i].Direction != nil
+			}
+			return favorites[i].DistanceM < favorites[j].DistanceM
+		})
+	}
+	return favorites, nil
+}
+
+// invalidateUser drops the cached copy of a user after their document changed
+func (s *UserService) invalidateUser(ctx context.Context, userID string) {
+	if s.redisClient == nil {
+		return
+	}
+	if err := s.redisClient.Del(ctx, "user:"+userID).Err(); err != nil {
+		log.Printf("Failed to invalidate cached user %s: %v", userID, err)
+	}
+}
@@ -2,22 +2,42 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
+	"go-server/breaker"
+	"go-server/cluster"
+	"go-server/geocode"
 	"go-server/models"
+	"go-server/search"
+	"go-server/taxonomy"
+	"go-server/utils/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"net/http"
 	"os"
+	"sort"
 	"strconv"
+	"sync"
+	"time"
 )
 
 type GeoService struct {
-	collection  *mongo.Collection
-	pois        []models.POI  // In-memory cache of POIs
-	RedisClient *redis.Client // Redis client for geo queries
+	collection   *mongo.Collection
+	RedisClient  *redis.Client // Redis client for users, suggestions and tags, nil without Redis
+	store        POIStore      // Answers POI geo queries, see POI_STORE
+	mongoStore   *MongoStore   // Fallback for geo queries while Redis is failing
+	redisBreaker *breaker.Breaker
+	clustersMu   sync.Mutex
+	clusters     *cluster.Index      // Map clusters of every zoom level, nil when stale
+	placement    uint64              // Fingerprint of the POIs placed at the last sync
+	nearbyCache  nearbyCache         // Nearby store hits per geohash cell
+	boundaries   *geocode.Boundaries // Administrative areas for reverse geocoding, may be nil
+	geocoder     *geocode.Index      // POI addresses and names for forward geocoding
+	searchIndex  *search.Index       // In-process full-text index, rebuilt on every sync
+	taxonomy     *taxonomy.Taxonomy
 }
 
 func NewGeoService() *GeoService {
@@ -40,29 +60,41 @@ func NewGeoService() *GeoService {
 	}
 	log.Println("Connected to MongoDB")
 	collection := client.Database("poi_db").Collection("pois")
+	ensureExternalIDIndex(collection)
 
 	// Instantiate GeoService with MongoDB collection
-	service := &GeoService{collection: collection} // Initialize GeoService with collection
-
-	// Initialize Redis client
-	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
-		log.Fatal("REDIS_ADDR environment variable is not set")
+	service := &GeoService{collection: collection, searchIndex: search.NewIndex(), geocoder: geocode.NewIndex()} // Initialize GeoService with collection
+	service.mongoStore = NewMongoStore(collection)
+	service.redisBreaker = breaker.New("redis", redisFailureThreshold, redisRetryInterval)
+	service.taxonomy, err = loadTaxonomy(context.Background(), client.Database("poi_db").Collection("categories"))
+	if err != nil {
+		log.Fatalf("Failed to load categories: %v", err)
 	}
-	redisDBStr := os.Getenv("REDIS_DB")
-	if redisDBStr == "" {
-		log.Fatal("REDIS_DB environment variable is not set")
+	boundariesFile := os.Getenv("BOUNDARIES_FILE")
+	if boundariesFile == "" {
+		boundariesFile = "./data/sg-regions.geojson"
 	}
-	redisDB, err := strconv.Atoi(redisDBStr)
+	service.boundaries = loadBoundaries(boundariesFile)
+	if timezone := os.Getenv("POI_DEFAULT_TIMEZONE"); timezone != "" {
+		if err := SetDefaultPOITimezone(timezone); err != nil {
+			log.Fatalf("Invalid POI_DEFAULT_TIMEZONE value: %v", err)
+		}
+	}
+
+	// Redis is only required by the Redis store, without it the features
+	// that live in Redis answer ErrRedisUnavailable
+	storeKind := POIStoreKind(os.Getenv("POI_STORE"))
+	redisRequired := storeKind == "" || storeKind == RedisPOIStore
+	service.RedisClient, err = connectRedis()
 	if err != nil {
-		log.Fatalf("Invalid REDIS_DB value: %v", err)
+		if redisRequired {
+			log.Fatal(err)
+		}
+		log.Printf("Running without Redis, so suggestions, the tile cache, nearby users and check-ins are disabled: %v", err)
 	}
-	service.RedisClient = redis.NewClient(&redis.Options{
-		Addr: redisAddr, // Redis server address
-		DB:   redisDB,   // Use default DB
-	})
-	if err := service.RedisClient.Ping(context.Background()).Err(); err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+	service.store, err = service.newPOIStore(storeKind)
+	if err != nil {
+		log.Fatalf("Invalid POI_STORE value: %v", err)
 	}
 
 	// Seed sample data if collection is empty
@@ -75,116 +107,269 @@ func NewGeoService() *GeoService {
 		log.Println("No POIs found in MongoDB, seeding sample data...")
 		// Seed sample POIs into MongoDB
 		service.seedPOIsToMongo(collection)
-		// Load POIs into memory
-		service.seedPOIsToRedis()
-	} else {
-		// If POIs exist, load them into redis
-		// Seed Redis with POIs
-		service.seedPOIsToRedis()
+	}
+	if err := service.classifyUnknownPOIs(context.Background()); err != nil {
+		log.Printf("Failed to classify POIs: %v", err)
+	}
+	if err := service.assignMissingCells(context.Background()); err != nil {
+		log.Printf("Failed to assign grid cells to POIs: %v", err)
+	}
+	// Bring the POI store in line with MongoDB without touching non-POI keys
+	if _, err := service.SyncPOIs(context.Background()); err != nil {
+		log.Printf("Failed to sync POIs: %v", err)
 	}
 
 	return service
 }
 
-// FindNearbyPOIs with Redis
-func (s *GeoService) FindNearbyPOIs(ctx context.Context, lat, lon, radius float64, poiType string) ([]models.POI, error) {
-	geoResults, err := s.RedisClient.GeoRadius(ctx, "pois:geo", lon, lat, &redis.GeoRadiusQuery{
-		Radius:    radius,
-		Unit:      "km",
-		WithCoord: true,
-		WithDist:  true,
-		Sort:      "ASC",
-		Count:     50,
-	}).Result()
+// ErrRedisUnavailable is returned by features that need Redis when the
+// server runs without it, see POI_STORE
+var ErrRedisUnavailable = errors.NewAPIError("REDIS_UNAVAILABLE", "This feature needs Redis, which isn't configured", http.StatusServiceUnavailable)
+
+// connectRedis connects to the Redis server in REDIS_ADDR and REDIS_DB
+func connectRedis() (*redis.Client, error) {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		return nil, fmt.Errorf("REDIS_ADDR environment variable is not set")
+	}
+	redisDBStr := os.Getenv("REDIS_DB")
+	if redisDBStr == "" {
+		return nil, fmt.Errorf("REDIS_DB environment variable is not set")
+	}
+	redisDB, err := strconv.Atoi(redisDBStr)
 	if err != nil {
-		log.Printf("Redis GeoRadius error: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("invalid REDIS_DB value: %v", err)
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: redisAddr, // Redis server address
+		DB:   redisDB,
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
 	}
+	return client, nil
+}
 
-	var results []models.POI
-	for _, geoResult := range geoResults {
-		poiJSON, err := s.RedisClient.HGet(ctx, geoResult.Name, "data").Result()
-		if err != nil {
-			log.Printf("Redis Get error for POI %s: %v", geoResult.Name, err)
-			continue
-		}
-		var poi models.POI
-		if err := json.Unmarshal([]byte(poiJSON), &poi); err != nil {
-			log.Printf("Failed to unmarshal POI %s: %v", geoResult.Name, err)
-			continue
-		}
-		// Skip if type filter doesn't match
-		if poiType != "" && poi.Type != poiType {
-			continue
-		}
-		distance := geoResult.Dist * 1000 // Convert km to meters
-		if distance <= radius {
-			poiRes := models.POI{
-				ID:          poi.ID,
-				Name:        poi.Name,
-				Description: poi.Description,
-				Type:        poi.Type,
-				Location:    poi.Location,
-				Tags:        poi.Tags,
-				Address:     poi.Address,
-			}
-			results = append(results, poiRes)
-		}
+const (
+	defaultNearbyLimit = 50
+	maxNearbyLimit     = 200
+)
+
+// NearbyPOIQuery describes a page of POIs around a point. Radius is in
+// meters, Units only controls the unit of the returned distances.
+type NearbyPOIQuery struct {
+	Lat       float64
+	Lon       float64
+	Radius    float64
+	Type      string
+	MinRating float64   // Only POIs with at least this average rating, 0 means any
+	OpenAt    time.Time // Only POIs open at this time, zero means any
+	Category  string    // Only POIs of this category or one below it
+	Tags      TagFilter
+
+	categoryTypes map[string]bool // Category resolved to POI types
+	tagsInProcess bool            // Check Tags against the loaded POIs instead of the Redis tag sets
+	Sort          NearbySort
+	Limit         int
+	Cursor        string
+	Units         DistanceUnit
+}
+
+// NearbySort is the order of nearby results
+type NearbySort string
+
+const (
+	SortByDistance NearbySort = "distance"
+	SortByRating   NearbySort = "rating" // Best rated first, then nearest
+)
+
+// ParseNearbySort parses a sort query parameter, defaulting to distance
+func ParseNearbySort(s string) (NearbySort, error) {
+	switch order := NearbySort(s); order {
+	case "":
+		return SortByDistance, nil
+	case SortByDistance, SortByRating:
+		return order, nil
+	}
+	return "", errors.NewAPIError("INVALID_SORT", "Invalid sort", http.StatusBadRequest, "expected distance or rating")
+}
+
+// matches reports whether a loaded POI passes the query filters
+func (q NearbyPOIQuery) matches(poi *models.POI) bool {
+	if poi == nil {
+		return false
+	}
+	if q.Type != "" && poi.Type != q.Type {
+		return false
+	}
+	if q.categoryTypes != nil && !q.categoryTypes[poi.Type] {
+		return false
 	}
+	if poi.RatingAvg < q.MinRating {
+		return false
+	}
+	if q.tagsInProcess && !q.Tags.matchesPOI(*poi) {
+		return false
+	}
+	return q.OpenAt.IsZero() || poiOpenAt(*poi, q.OpenAt)
+}
+
+// nearbyPOI adds the direction from the queried point to a POI
+func (q NearbyPOIQuery) nearbyPOI(poi models.POI, distance float64) NearbyPOI {
+	lon, lat := q.Lon, q.Lat
+	if len(poi.Location.Coordinates) == 2 {
+		lon, lat = poi.Location.Coordinates[0], poi.Location.Coordinates[1]
+	}
+	return NearbyPOI{POI: poi, Direction: newDirection(q.Lon, q.Lat, lon, lat, distance, q.Units)}
+}
 
-	log.Printf("Found %d POIs within %f meters", len(results), radius)
-	// Sort by distance (closest first)
-	return results, nil
+// NearbyPOI is a POI with its distance and bearing from the queried point
+type NearbyPOI struct {
+	models.POI
+	Direction
 }
 
-// Seed Redis with POIs
-func (s *GeoService) seedPOIsToRedis() {
-	ctx := context.Background()
-	// Clear existing POI data in Redis
-	err := s.RedisClient.FlushDB(ctx).Err()
+// FindNearbyPOIs with the POI store, or MongoDB while Redis is failing.
+// Results are sorted by distance then ID, or by rating first with
+// SortByRating, and the returned cursor resumes after the last POI of the
+// page ("" on the last page).
+func (s *GeoService) FindNearbyPOIs(ctx context.Context, query NearbyPOIQuery) ([]NearbyPOI, string, error) {
+	cursor, err := decodeCursor(query.Cursor)
 	if err != nil {
-		log.Printf("Failed to flush Redis DB: %v", err)
-		return
+		return nil, "", err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultNearbyLimit
+	}
+	limit = min(limit, maxNearbyLimit)
+	if query.Units == "" {
+		query.Units = Meters
 	}
-	log.Println("Seeding POIs into Redis...")
-	// Take data from mongo and seed into Redis
+	if query.Category != "" {
+		if query.categoryTypes, err = s.categoryTypes(query.Category); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var results []NearbyPOI
+	var nextCursor string
+	err = s.withFallback(ctx, func(store POIStore, fallback bool) error {
+		// Tag sets live in Redis next to the Redis store, with any other
+		// store (the fallback included) the POIs' own tags are checked so
+		// its queries don't depend on Redis
+		_, redisStore := store.(*RedisStore)
+		query.tagsInProcess = !redisStore
+		results, nextCursor, err = s.findNearbyPOIs(ctx, store, query, cursor, limit)
+		return err
+	})
+	return results, nextCursor, err
+}
 
-	cursor, err := s.collection.Find(context.Background(), bson.M{})
+func (s *GeoService) findNearbyPOIs(ctx context.Context, store POIStore, query NearbyPOIQuery, cursor *pageCursor, limit int) ([]NearbyPOI, string, error) {
+	// Fetch every POI in range, stores can't offset so paging and type
+	// filtering happen on our side
+	hits, err := s.nearbyHits(ctx, store, query.Lon, query.Lat, query.Radius)
 	if err != nil {
-		log.Printf("Failed to load POIs from MongoDB: %v", err)
-		return
+		log.Printf("POI store nearby error: %v", err)
+		return nil, "", err
 	}
-	defer cursor.Close(context.Background())
-	var pois []models.POI
-	if err := cursor.All(context.Background(), &pois); err != nil {
-		log.Printf("Failed to decode POIs from MongoDB: %v", err)
-		return
+	// Break distance ties by ID so the cursor position is stable
+	sortHits(hits)
+	if !query.tagsInProcess {
+		if hits, err = s.filterByTags(ctx, hits, query.Tags); err != nil {
+			log.Printf("Redis tag filter error: %v", err)
+			return nil, "", err
+		}
 	}
-	// Iterate through each POI and store in Redis
-	for _, poi := range pois {
-		// Store POI data in Redis hash
-		poiJSON, err := json.Marshal(poi)
-		if err != nil {
-			log.Printf("Failed to marshal POI %s: %v", poi.Name, err)
-			continue
+	if query.Sort == SortByRating {
+		return findNearbyByRating(ctx, store, query, hits, cursor, limit)
+	}
+	candidates := hits[:0]
+	for _, hit := range hits {
+		if cursor.after(hit.Distance, hit.ID) {
+			candidates = append(candidates, hit)
 		}
-		err = s.RedisClient.HSet(ctx, poi.ID, "data", poiJSON).Err()
+	}
+
+	// Load POI data in batches until the page (plus one to detect more) is full
+	results := []NearbyPOI{}
+	var last POIHit
+	hasMore := false
+	for start := 0; start < len(candidates) && !hasMore; start += limit {
+		batch := candidates[start:min(start+limit, len(candidates))]
+		pois, err := loadPOIs(ctx, store, batch)
 		if err != nil {
-			log.Printf("Failed to set POI %s in Redis: %v", poi.Name, err)
-			continue
+			return nil, "", err
 		}
-		// Add to Redis Geo set
-		err = s.RedisClient.GeoAdd(ctx, "pois:geo", &redis.GeoLocation{
-			Name:      poi.ID,
-			Longitude: poi.Location.Coordinates[0],
-			Latitude:  poi.Location.Coordinates[1],
-		}).Err()
-		if err != nil {
-			log.Printf("Failed to add POI %s to Redis Geo set: %v", poi.Name, err)
-			continue
+
+		for i, hit := range batch {
+			// Skip POIs that are gone or don't pass the filters
+			if !query.matches(pois[i]) {
+				continue
+			}
+			if len(results) == limit {
+				hasMore = true
+				break
+			}
+			results = append(results, query.nearbyPOI(*pois[i], hit.Distance))
+			last = hit
+		}
+	}
+
+	nextCursor := ""
+	if hasMore {
+		nextCursor = encodeCursor(pageCursor{Distance: last.Distance, LastID: last.ID})
+	}
+	log.Printf("Found %d POIs within %f meters", len(results), query.Radius)
+	return results, nextCursor, nil
+}
+
+// findNearbyByRating pages through POIs in range, best rated first and then
+// nearest. Ratings live in the POI data, so every POI in range is loaded.
+func findNearbyByRating(ctx context.Context, store POIStore, query NearbyPOIQuery, hits []POIHit, cursor *pageCursor, limit int) ([]NearbyPOI, string, error) {
+	pois, err := loadPOIs(ctx, store, hits)
+	if err != nil {
+		return nil, "", err
+	}
+
+	type candidate struct {
+		poi      *models.POI
+		distance float64
+	}
+	var candidates []candidate
+	for i, hit := range hits {
+		if query.matches(pois[i]) && cursor.afterRated(pois[i].RatingAvg, hit.Distance, hit.ID) {
+			candidates = append(candidates, candidate{poi: pois[i], distance: hit.Distance})
 		}
 	}
-	log.Printf("Seeded %d POIs into Redis", len(pois))
+	// hits are already in distance order, a stable sort keeps it within equal ratings
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].poi.RatingAvg > candidates[j].poi.RatingAvg
+	})
+
+	results := make([]NearbyPOI, 0, min(limit, len(candidates)))
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		results = append(results, query.nearbyPOI(*c.poi, c.distance))
+	}
+	nextCursor := ""
+	if len(candidates) > limit {
+		last := candidates[limit-1]
+		nextCursor = encodeCursor(pageCursor{Rating: last.poi.RatingAvg, Distance: last.distance, LastID: last.poi.ID})
+	}
+	log.Printf("Found %d POIs within %f meters by rating", len(results), query.Radius)
+	return results, nextCursor, nil
+}
+
+// loadPOIs fetches the POIs behind store hits. The result is aligned with
+// hits, with nil entries for POIs that couldn't be loaded.
+func loadPOIs(ctx context.Context, store POIStore, hits []POIHit) ([]*models.POI, error) {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return store.Get(ctx, ids)
 }
 
 func (s *GeoService) seedPOIsToMongo(collection *mongo.Collection) {
@@ -197,24 +382,9 @@ func (s *GeoService) seedPOIsToMongo(collection *mongo.Collection) {
 	}
 	defer file.Close()
 
-	var pois []models.POI
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&pois); err != nil {
-		log.Fatalf("Failed to decode POI JSON: %v", err)
-		return
-	}
-
-	log.Printf("Seeding %d POIs into MongoDB...", len(pois))
-
-	// convert to interface{} for MongoDB
-	var interfacePois []any
-	for _, poi := range pois {
-		interfacePois = append(interfacePois, poi)
-	}
-
-	result, err := collection.InsertMany(context.Background(), interfacePois)
+	report, err := s.ImportPOIs(context.Background(), "json", file)
 	if err != nil {
 		log.Fatalf("Failed to seed POIs: %v", err)
 	}
-	log.Printf("Inserted %d POIs into MongoDB", len(result.InsertedIDs))
+	log.Printf("Inserted %d POIs into MongoDB", report.Inserted)
 }
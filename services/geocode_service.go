@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"go-server/geocode"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// reverseGeocodeRadii are the radii in meters searched in turn for the
+// nearest POI, so dense areas stay cheap and sparse ones still get an answer
+var reverseGeocodeRadii = []float64{100, 500, 2000}
+
+const (
+	defaultGeocodeLimit = 5
+	maxGeocodeLimit     = 20
+)
+
+// Place describes a position in words
+type Place struct {
+	// Label reads like "near National Gallery Singapore, St Andrew's Road"
+	Label  string     `json:"label"`
+	POI    *NearbyPOI `json:"poi,omitempty"` // Nearest POI, with distance and bearing from the position
+	Street string     `json:"street,omitempty"`
+	Area   string     `json:"area,omitempty"` // Administrative area containing the position
+}
+
+// loadBoundaries reads the administrative areas used for reverse geocoding.
+// Reverse geocoding works without them, only leaving out areas.
+func loadBoundaries(path string) *geocode.Boundaries {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("No boundaries loaded, reverse geocoding won't name areas: %v", err)
+		return nil
+	}
+	boundaries, err := geocode.LoadBoundaries(data)
+	if err != nil {
+		log.Printf("Failed to load boundaries from %s: %v", path, err)
+		return nil
+	}
+	log.Printf("Loaded %d boundaries from %s", boundaries.Len(), path)
+	return boundaries
+}
+
+// ReverseGeocode describes a position by its nearest POI, that POI's street
+// and the area it lies in
+func (s *GeoService) ReverseGeocode(ctx context.Context, lon, lat float64) (Place, error) {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return Place{}, errors.ErrInvalidInput
+	}
+
+	var place Place
+	if s.boundaries != nil {
+		if area := s.boundaries.Locate(lon, lat); area != nil {
+			place.Area = area.Name
+		}
+	}
+
+	var nearest *models.POI
+	var distance float64
+	err := s.withFallback(ctx, func(store POIStore, fallback bool) error {
+		for _, radius := range reverseGeocodeRadii {
+			hits, err := s.nearbyHits(ctx, store, lon, lat, radius)
+			if err != nil {
+				return err
+			}
+			sortHits(hits)
+			// Load a few in case the nearest ones vanished since the last sync
+			pois, err := loadPOIs(ctx, store, hits[:min(len(hits), 5)])
+			if err != nil {
+				return err
+			}
+			for i, poi := range pois {
+				if poi != nil {
+					nearest, distance = poi, hits[i].Distance
+					return nil
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Place{}, err
+	}
+
+	var label []string
+	if nearest != nil {
+		nearbyPOI := NearbyPOIQuery{Lon: lon, Lat: lat, Units: Meters}.nearbyPOI(*nearest, distance)
+		place.POI = &nearbyPOI
+		place.Street = geocode.Street(nearest.Address)
+		label = append(label, "near "+nearest.Name)
+		if place.Street != "" && place.Street != nearest.Name {
+			label = append(label, place.Street)
+		}
+	} else if place.Area != "" {
+		label = append(label, place.Area)
+	}
+	place.Label = strings.Join(label, ", ")
+	return place, nil
+}
+
+// Geocode resolves an address, postal code or place name to coordinates from
+// the POIs' own addresses, most confident first
+func (s *GeoService) Geocode(ctx context.Context, query string, limit int) ([]geocode.Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.NewAPIError("INVALID_QUERY", "Geocoding query is required", http.StatusBadRequest)
+	}
+	if limit <= 0 {
+		limit = defaultGeocodeLimit
+	}
+	limit = min(limit, maxGeocodeLimit)
+	results := s.geocoder.Geocode(query, limit)
+	if results == nil {
+		results = []geocode.Result{}
+	}
+	return results, nil
+}
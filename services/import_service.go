@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"go-server/importers"
+	"go-server/models"
+	"go-server/utils/errors"
+	"io"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportRowError describes why a single row of an import was rejected
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarises a bulk POI import
+type ImportReport struct {
+	Format   string           `json:"format"`
+	Total    int              `json:"total"`
+	Inserted int              `json:"inserted"`
+	Updated  int              `json:"updated"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ensureExternalIDIndex makes external IDs unique so re-imports upsert
+func ensureExternalIDIndex(collection *mongo.Collection) {
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "external_id", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"external_id": bson.M{"$exists": true}}),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		log.Printf("Failed to create unique index on POI external_id: %v", err)
+	}
+}
+
+// ImportPOIs reads POIs in the given format and upserts them by external ID
+func (s *GeoService) ImportPOIs(ctx context.Context, format string, r io.Reader) (ImportReport, error) {
+	report := ImportReport{Format: format, Errors: []ImportRowError{}}
+
+	importer, err := importers.Get(format)
+	if err != nil {
+		return report, errors.NewAPIError("UNSUPPORTED_FORMAT", "Unsupported import format", http.StatusBadRequest, err.Error())
+	}
+	records, err := importer.Import(r)
+	if err != nil {
+		return report, errors.NewAPIError("INVALID_IMPORT", "Failed to read import file", http.StatusBadRequest, err.Error())
+	}
+
+	for _, record := range records {
+		report.Total++
+		if record.Err == nil {
+			s.classifyPOI(&record.POI)
+			record.Err = validatePOI(record.POI)
+		}
+		if record.Err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: record.Row, Error: record.Err.Error()})
+			continue
+		}
+
+		inserted, err := s.upsertPOIByExternalID(ctx, record.POI)
+		if err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: record.Row, Error: err.Error()})
+			continue
+		}
+		if inserted {
+			report.Inserted++
+		} else {
+			report.Updated++
+		}
+	}
+
+	log.Printf("Imported %s POIs: %d inserted, %d updated, %d failed", format, report.Inserted, report.Updated, report.Failed)
+	return report, nil
+}
+
+// upsertPOIByExternalID writes a POI keyed by its external ID and indexes it in Redis.
+// POIs created through the API have no external ID, so exports of them are
+// matched by their own ID instead.
+func (s *GeoService) upsertPOIByExternalID(ctx context.Context, poi models.POI) (bool, error) {
+	filter := bson.M{"external_id": poi.ExternalID}
+	// The previous version tells which suggestions and tags to drop
+	var previous *models.POI
+	var existing models.POI
+	if objID, err := primitive.ObjectIDFromHex(poi.ID); err == nil {
+		idFilter := bson.M{"_id": objID, "external_id": bson.M{"$exists": false}}
+		if err := s.collection.FindOne(ctx, idFilter).Decode(&existing); err == nil {
+			filter, previous = bson.M{"_id": objID}, &existing
+			poi.ExternalID = ""
+		} else if err != mongo.ErrNoDocuments {
+			return false, err
+		}
+	}
+	poi.ID = ""
+	// Zero values are left out of the $set, keeping the ratings, photos, opening
+	// hours and timezone of existing POIs when the source doesn't have them
+	poi.RatingAvg, poi.RatingCount = 0, 0
+	poi.Photos = nil
+	assignCells(&poi)
+	if previous == nil {
+		if err := s.collection.FindOne(ctx, filter).Decode(&existing); err == nil {
+			previous = &existing
+		} else if err != mongo.ErrNoDocuments {
+			return false, err
+		}
+	}
+	result, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": poi}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+
+	inserted := result.UpsertedID != nil
+	if inserted {
+		poi.ID = result.UpsertedID.(primitive.ObjectID).Hex()
+	} else {
+		var updated models.POI
+		if err := s.collection.FindOne(ctx, filter).Decode(&updated); err != nil {
+			return false, err
+		}
+		poi = updated
+	}
+	s.reindexPOI(ctx, poi, previous)
+	return inserted, nil
+}
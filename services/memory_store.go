@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/rtree"
+	"math"
+	"sync"
+)
+
+// MemoryStore keeps POIs in process, with an R-tree for spatial queries. It
+// needs no Redis for POI queries, which suits local development. The tree
+// is immutable and rebuilt on the first query after a change.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	pois         map[string]models.POI
+	tree         *rtree.Tree
+	dirty        bool
+	fingerprints map[string][sha1.Size]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		pois:         map[string]models.POI{},
+		tree:         rtree.New(nil),
+		fingerprints: map[string][sha1.Size]byte{},
+	}
+}
+
+// search returns the positions inside a lon/lat rectangle
+func (s *MemoryStore) search(r rtree.Rect) []POIHit {
+	s.mu.RLock()
+	if s.dirty {
+		s.mu.RUnlock()
+		s.mu.Lock()
+		if s.dirty {
+			items := make([]rtree.Item, 0, len(s.pois))
+			for id, poi := range s.pois {
+				items = append(items, rtree.Item{ID: id, X: poi.Location.Coordinates[0], Y: poi.Location.Coordinates[1]})
+			}
+			s.tree = rtree.New(items)
+			s.dirty = false
+		}
+		s.mu.Unlock()
+		s.mu.RLock()
+	}
+	defer s.mu.RUnlock()
+
+	var hits []POIHit
+	s.tree.Search(r, func(item rtree.Item) bool {
+		hits = append(hits, POIHit{ID: item.ID, Lon: item.X, Lat: item.Y})
+		return true
+	})
+	return hits
+}
+
+// Nearby searches the bounding box of the circle, then keeps what's in range
+func (s *MemoryStore) Nearby(ctx context.Context, lon, lat, radius float64) ([]POIHit, error) {
+	dLat := radius / geometry.EarthRadiusMeters * 180 / math.Pi
+	box := BoundingBox{MinLon: -180, MinLat: math.Max(lat-dLat, -90), MaxLon: 180, MaxLat: math.Min(lat+dLat, 90)}
+	// Near the poles the circle covers every longitude
+	if cos := math.Cos(math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat)) * math.Pi / 180); box.MinLat > -90 && box.MaxLat < 90 && dLat/cos < 180 {
+		dLon := dLat / cos
+		box.MinLon, box.MaxLon = wrapLon(lon-dLon), wrapLon(lon+dLon)
+	}
+
+	var hits []POIHit
+	for _, part := range box.split() {
+		for _, hit := range s.search(rtree.Rect{MinX: part.MinLon, MinY: part.MinLat, MaxX: part.MaxLon, MaxY: part.MaxLat}) {
+			if hit.Distance = geometry.Distance(lon, lat, hit.Lon, hit.Lat); hit.Distance <= radius {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	sortHits(hits)
+	return hits, nil
+}
+
+func (s *MemoryStore) Within(ctx context.Context, box BoundingBox, count int) ([]POIHit, error) {
+	hits := s.search(rtree.Rect{MinX: box.MinLon, MinY: box.MinLat, MaxX: box.MaxLon, MaxY: box.MaxLat})
+	centerLon, centerLat := (box.MinLon+box.MaxLon)/2, (box.MinLat+box.MaxLat)/2
+	for i := range hits {
+		hits[i].Distance = geometry.Distance(centerLon, centerLat, hits[i].Lon, hits[i].Lat)
+	}
+	sortHits(hits)
+	if count > 0 && len(hits) > count {
+		hits = hits[:count]
+	}
+	for i := range hits {
+		hits[i].Distance = 0
+	}
+	return hits, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, ids []string) ([]*models.POI, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pois := make([]*models.POI, len(ids))
+	for i, id := range ids {
+		if poi, ok := s.pois[id]; ok {
+			pois[i] = &poi
+		}
+	}
+	return pois, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, poi models.POI) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pois[poi.ID] = poi
+	s.dirty = true
+	return nil
+}
+
+func (s *MemoryStore) Remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pois[id]; ok {
+		delete(s.pois, id)
+		s.dirty = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Sync(ctx context.Context, pois []models.POI) (POISyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, fingerprints := diffFingerprints(s.fingerprints, pois)
+	s.fingerprints = fingerprints
+	s.pois = make(map[string]models.POI, len(pois))
+	for _, poi := range pois {
+		s.pois[poi.ID] = poi
+	}
+	s.dirty = true
+	return result, nil
+}
+
+// wrapLon brings a longitude back into [-180, 180]
+func wrapLon(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon < -180 {
+		lon += 360
+	}
+	return lon
+}
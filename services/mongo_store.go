@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore queries the POI collection directly through its 2dsphere index.
+// The collection is written by GeoService, so Put and Remove have nothing
+// to do.
+type MongoStore struct {
+	collection *mongo.Collection
+
+	mu           sync.Mutex
+	fingerprints map[string][sha1.Size]byte // Last synced version of each POI, to report changes
+}
+
+// NewMongoStore makes sure the 2dsphere index geo queries need exists
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	indexModel := mongo.IndexModel{Keys: bson.D{{Key: "location", Value: "2dsphere"}}}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		log.Printf("Failed to create 2dsphere index on POI location: %v", err)
+	}
+	return &MongoStore{collection: collection, fingerprints: map[string][sha1.Size]byte{}}
+}
+
+// positionsOnly keeps query results down to what a POIHit needs
+var positionsOnly = options.Find().SetProjection(bson.M{"_id": 1, "location": 1})
+
+// Nearby runs a $nearSphere query, which returns POIs nearest first. It
+// doesn't report distances, so they are computed from the positions.
+func (s *MongoStore) Nearby(ctx context.Context, lon, lat, radius float64) ([]POIHit, error) {
+	filter := bson.M{
+		"location": bson.M{
+			"$nearSphere": bson.M{
+				"$geometry":    bson.M{"type": "Point", "coordinates": bson.A{lon, lat}},
+				"$maxDistance": radius,
+			},
+		},
+	}
+	cursor, err := s.collection.Find(ctx, filter, positionsOnly)
+	if err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to query nearby POIs", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to decode nearby POIs", http.StatusInternalServerError)
+	}
+	hits := make([]POIHit, 0, len(pois))
+	for _, poi := range pois {
+		if len(poi.Location.Coordinates) == 2 {
+			poiLon, poiLat := poi.Location.Coordinates[0], poi.Location.Coordinates[1]
+			hits = append(hits, POIHit{ID: poi.ID, Lon: poiLon, Lat: poiLat, Distance: geometry.Distance(lon, lat, poiLon, poiLat)})
+		}
+	}
+	return hits, nil
+}
+
+func (s *MongoStore) Within(ctx context.Context, box BoundingBox, count int) ([]POIHit, error) {
+	// A box without area isn't a valid polygon
+	if box.empty() {
+		return nil, nil
+	}
+	var pieces bson.A
+	for _, polygon := range box.polygons() {
+		pieces = append(pieces, bson.M{
+			"location": bson.M{
+				"$geoWithin": bson.M{
+					"$geometry": bson.M{"type": "Polygon", "coordinates": polygon},
+				},
+			},
+		})
+	}
+	filter := bson.M{"$or": pieces}
+	cursor, err := s.collection.Find(ctx, filter, positionsOnly)
+	if err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to query POIs", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to decode POIs", http.StatusInternalServerError)
+	}
+
+	// Order by distance from the center like the other stores
+	centerLon, centerLat := (box.MinLon+box.MaxLon)/2, (box.MinLat+box.MaxLat)/2
+	hits := make([]POIHit, 0, len(pois))
+	for _, poi := range pois {
+		if len(poi.Location.Coordinates) == 2 {
+			lon, lat := poi.Location.Coordinates[0], poi.Location.Coordinates[1]
+			hits = append(hits, POIHit{ID: poi.ID, Lon: lon, Lat: lat, Distance: geometry.Distance(centerLon, centerLat, lon, lat)})
+		}
+	}
+	sortHits(hits)
+	if count > 0 && len(hits) > count {
+		hits = hits[:count]
+	}
+	for i := range hits {
+		hits[i].Distance = 0
+	}
+	return hits, nil
+}
+
+func (s *MongoStore) Get(ctx context.Context, ids []string) ([]*models.POI, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	pois := make([]*models.POI, len(ids))
+	if len(objIDs) == 0 {
+		return pois, nil
+	}
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to get POIs", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	var found []models.POI
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to decode POIs", http.StatusInternalServerError)
+	}
+	byID := make(map[string]*models.POI, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+	for i, id := range ids {
+		pois[i] = byID[id]
+	}
+	return pois, nil
+}
+
+func (s *MongoStore) Put(ctx context.Context, poi models.POI) error {
+	return nil
+}
+
+func (s *MongoStore) Remove(ctx context.Context, id string) error {
+	return nil
+}
+
+// Sync only compares the POIs with the previous sync, the data is already in place
+func (s *MongoStore) Sync(ctx context.Context, pois []models.POI) (POISyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, fingerprints := diffFingerprints(s.fingerprints, pois)
+	s.fingerprints = fingerprints
+	return result, nil
+}
+
+// diffFingerprints compares POIs against the fingerprints of a previous set
+// and returns the changes along with the fingerprints of the new set
+func diffFingerprints(previous map[string][sha1.Size]byte, pois []models.POI) (POISyncResult, map[string][sha1.Size]byte) {
+	var result POISyncResult
+	fingerprints := make(map[string][sha1.Size]byte, len(pois))
+	for _, poi := range pois {
+		poiJSON, err := json.Marshal(poi)
+		if err != nil {
+			continue
+		}
+		fingerprint := sha1.Sum(poiJSON)
+		fingerprints[poi.ID] = fingerprint
+		old, ok := previous[poi.ID]
+		switch {
+		case !ok:
+			result.Added++
+		case old != fingerprint:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	for id := range previous {
+		if _, ok := fingerprints[id]; !ok {
+			result.Removed++
+		}
+	}
+	return result, fingerprints
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"go-server/geo/cell"
+	"go-server/geometry"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Cached hits expire so edits made on other replicas show up, edits
+	// made here clear the cache right away
+	nearbyCacheTTL     = 30 * time.Second
+	nearbyCacheEntries = 10000
+)
+
+// nearbyCache holds the store hits around geohash cells, so nearby queries
+// from anywhere in a cell share one store query
+type nearbyCache struct {
+	mu      sync.Mutex
+	entries map[string]nearbyCacheEntry
+}
+
+type nearbyCacheEntry struct {
+	hits    []POIHit
+	expires time.Time
+}
+
+func (c *nearbyCache) get(key string) ([]POIHit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.hits, true
+}
+
+func (c *nearbyCache) put(key string, hits []POIHit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= nearbyCacheEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	// Still full of live entries, start over rather than track recency
+	if c.entries == nil || len(c.entries) >= nearbyCacheEntries {
+		c.entries = map[string]nearbyCacheEntry{}
+	}
+	c.entries[key] = nearbyCacheEntry{hits: hits, expires: now.Add(nearbyCacheTTL)}
+}
+
+func (c *nearbyCache) clear() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+// nearbyHits answers store.Nearby through the cache. The cell around the
+// queried point caches every POI within radius of any point in it, so a
+// query only needs its distances recomputed and the hits beyond radius
+// dropped.
+func (s *GeoService) nearbyHits(ctx context.Context, store POIStore, lon, lat, radius float64) ([]POIHit, error) {
+	hash, reach, ok := nearbyCacheCell(lon, lat, radius)
+	if !ok {
+		return store.Nearby(ctx, lon, lat, radius)
+	}
+	key := hash.ID() + ":" + strconv.FormatFloat(radius, 'g', -1, 64)
+	cached, ok := s.nearbyCache.get(key)
+	if !ok {
+		centerLon, centerLat := hash.Center()
+		var err error
+		// Pad a little, stores may measure on a slightly different Earth radius
+		if cached, err = store.Nearby(ctx, centerLon, centerLat, (radius+reach)*1.001); err != nil {
+			return nil, err
+		}
+		s.nearbyCache.put(key, cached)
+	}
+
+	hits := make([]POIHit, 0, len(cached))
+	for _, hit := range cached {
+		if hit.Distance = geometry.Distance(lon, lat, hit.Lon, hit.Lat); hit.Distance <= radius {
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
+
+// nearbyCacheCell picks the largest geohash cell around a point that reaches
+// no further than a quarter of the radius from its center, which keeps the
+// cached area within about 1.6 times the queried one. reach is the distance
+// from the center to the farthest corner.
+func nearbyCacheCell(lon, lat, radius float64) (hash cell.Geohash, reach float64, ok bool) {
+	for precision := 1; precision <= cell.MaxGeohashPrecision; precision++ {
+		hash = cell.EncodeGeohash(lon, lat, precision)
+		centerLon, centerLat := hash.Center()
+		minLon, minLat, maxLon, maxLat := hash.Bounds()
+		reach = max(
+			geometry.Distance(centerLon, centerLat, minLon, minLat),
+			geometry.Distance(centerLon, centerLat, minLon, maxLat),
+			geometry.Distance(centerLon, centerLat, maxLon, minLat),
+			geometry.Distance(centerLon, centerLat, maxLon, maxLat),
+		)
+		if reach <= radius/4 {
+			return hash, reach, true
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"go-server/hours"
+	"go-server/models"
+	"log"
+	"sync"
+	"time"
+	_ "time/tzdata" // POI time zones must resolve even without system zoneinfo
+)
+
+// DefaultPOITimezone is the zone opening hours are read in for POIs without
+// a timezone, unless POI_DEFAULT_TIMEZONE says otherwise
+const DefaultPOITimezone = "Asia/Singapore"
+
+// defaultPOILocation is set once on startup, before any query runs
+var defaultPOILocation = mustLoadLocation(DefaultPOITimezone)
+
+// Parsed schedules and loaded time zones, shared by all queries. POIs repeat
+// the same few expressions and zones, so these stay small.
+var (
+	scheduleCache sync.Map // opening hours expression -> *hours.Schedule, nil when invalid
+	locationCache sync.Map // time zone name -> *time.Location, nil when unknown
+)
+
+// poiOpenAt reports whether a POI is open at t in its own time zone. POIs
+// without opening hours count as closed, since we can't tell.
+func poiOpenAt(poi models.POI, t time.Time) bool {
+	if poi.OpeningHours == "" {
+		return false
+	}
+	cached, ok := scheduleCache.Load(poi.OpeningHours)
+	if !ok {
+		schedule, err := hours.Parse(poi.OpeningHours)
+		if err != nil {
+			log.Printf("Invalid opening hours on POI %s: %v", poi.ID, err)
+			schedule = nil
+		}
+		cached, _ = scheduleCache.LoadOrStore(poi.OpeningHours, schedule)
+	}
+	schedule := cached.(*hours.Schedule)
+	if schedule == nil {
+		return false
+	}
+	return schedule.OpenAt(t.In(poiLocation(poi.Timezone)))
+}
+
+// SetDefaultPOITimezone changes the zone used for POIs without a timezone
+func SetDefaultPOITimezone(name string) error {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("unknown timezone %s: %v", name, err)
+	}
+	defaultPOILocation = location
+	return nil
+}
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+// poiLocation loads a POI time zone, falling back to the default zone
+func poiLocation(name string) *time.Location {
+	if name == "" {
+		return defaultPOILocation
+	}
+	cached, ok := locationCache.Load(name)
+	if !ok {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("Unknown POI timezone %s: %v", name, err)
+			location = nil
+		}
+		cached, _ = locationCache.LoadOrStore(name, location)
+	}
+	if location := cached.(*time.Location); location != nil {
+		return location
+	}
+	return defaultPOILocation
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"go-server/models"
+	"testing"
+	"time"
+)
+
+func TestPOILocationFallsBackToDefault(t *testing.T) {
+	if got := poiLocation("").String(); got != DefaultPOITimezone {
+		t.Errorf("poiLocation(\"\") = %s, want %s", got, DefaultPOITimezone)
+	}
+	if got := poiLocation("Not/AZone").String(); got != DefaultPOITimezone {
+		t.Errorf("poiLocation of an unknown zone = %s, want %s", got, DefaultPOITimezone)
+	}
+	if got := poiLocation("Europe/London").String(); got != "Europe/London" {
+		t.Errorf("poiLocation(\"Europe/London\") = %s", got)
+	}
+}
+
+func TestPOIOpenAtDefaultTimezone(t *testing.T) {
+	defer SetDefaultPOITimezone(DefaultPOITimezone)
+
+	poi := models.POI{ID: "1", OpeningHours: "Mo-Fr 09:00-17:00"}
+	// 10:00 in Singapore, 02:00 in UTC
+	at := time.Date(2024, time.March, 4, 2, 0, 0, 0, time.UTC)
+	if !poiOpenAt(poi, at) {
+		t.Errorf("POI without a timezone is closed at 10:00 Singapore time")
+	}
+	if err := SetDefaultPOITimezone("UTC"); err != nil {
+		t.Fatal(err)
+	}
+	if poiOpenAt(poi, at) {
+		t.Errorf("POI without a timezone is open at 02:00 UTC with a UTC default")
+	}
+	poi.Timezone = "Asia/Singapore"
+	if !poiOpenAt(poi, at) {
+		t.Errorf("POI in Asia/Singapore is closed at 10:00 local time")
+	}
+	if err := SetDefaultPOITimezone("Not/AZone"); err == nil {
+		t.Errorf("SetDefaultPOITimezone of an unknown zone succeeded")
+	}
+}
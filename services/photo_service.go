@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-server/imaging"
+	"go-server/models"
+	"go-server/storage"
+	"go-server/utils/errors"
+	"image"
+	"image/jpeg"
+	_ "image/png" // Register the PNG decoder
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	maxPhotosPerPOI  = 20
+	maxPhotoPixels   = 40_000_000 // Guards against decompression bombs
+	thumbnailSize    = 320
+	thumbnailQuality = 80
+	// defaultMaxPhotoBytes applies when MAX_PHOTO_BYTES isn't set
+	defaultMaxPhotoBytes = 10 << 20
+)
+
+// photoExtensions are the accepted photo content types
+var photoExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+type PhotoService struct {
+	geoService *GeoService
+	store      storage.BlobStore
+	maxBytes   int64
+}
+
+func NewPhotoService(geoService *GeoService, store storage.BlobStore, maxBytes int64) *PhotoService {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxPhotoBytes
+	}
+	return &PhotoService{geoService: geoService, store: store, maxBytes: maxBytes}
+}
+
+// MaxBytes is the largest accepted photo file
+func (s *PhotoService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// UploadPhoto attaches an image to a POI. The content type is sniffed from
+// the data, location metadata is stripped before storing and a JPEG
+// thumbnail is generated.
+func (s *PhotoService) UploadPhoto(ctx context.Context, poiID string, data []byte) (models.Photo, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return models.Photo{}, errors.ErrUnauthorized
+	}
+	if int64(len(data)) > s.maxBytes {
+		return models.Photo{}, errors.NewAPIError("PHOTO_TOO_LARGE", "Photo is too large", http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("maximum size is %d bytes", s.maxBytes))
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := photoExtensions[contentType]
+	if !ok {
+		return models.Photo{}, errors.NewAPIError("UNSUPPORTED_MEDIA_TYPE", "Unsupported photo type", http.StatusUnsupportedMediaType, "expected a JPEG or PNG image")
+	}
+	objID, err := poiObjectID(poiID)
+	if err != nil {
+		return models.Photo{}, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return models.Photo{}, errors.NewAPIError("INVALID_IMAGE", "Invalid image", http.StatusBadRequest, err.Error())
+	}
+	if config.Width*config.Height > maxPhotoPixels {
+		return models.Photo{}, errors.NewAPIError("INVALID_IMAGE", "Invalid image", http.StatusBadRequest, "image dimensions are too large")
+	}
+	stripped, err := imaging.StripGPS(data, contentType)
+	if err != nil {
+		return models.Photo{}, errors.NewAPIError("INVALID_IMAGE", "Invalid image", http.StatusBadRequest, err.Error())
+	}
+	img, _, err := image.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return models.Photo{}, errors.NewAPIError("INVALID_IMAGE", "Invalid image", http.StatusBadRequest, err.Error())
+	}
+	orientation := imaging.Orientation(stripped)
+	var thumbnail bytes.Buffer
+	if err := jpeg.Encode(&thumbnail, imaging.Thumbnail(img, orientation, thumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return models.Photo{}, errors.Wrap(err, "IMAGE_ERROR", "Failed to create thumbnail", http.StatusInternalServerError)
+	}
+
+	id := uuid.New().String()
+	photo := models.Photo{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  contentType,
+		Width:        config.Width,
+		Height:       config.Height,
+		CreatedAt:    time.Now().UTC(),
+		Key:          fmt.Sprintf("pois/%s/%s.%s", poiID, id, ext),
+		ThumbnailKey: fmt.Sprintf("pois/%s/%s_thumb.jpg", poiID, id),
+	}
+	if orientation >= 5 {
+		photo.Width, photo.Height = photo.Height, photo.Width
+	}
+	photo.URL = s.store.URL(photo.Key)
+	photo.ThumbnailURL = s.store.URL(photo.ThumbnailKey)
+
+	if err := s.store.Put(ctx, photo.Key, bytes.NewReader(stripped), contentType); err != nil {
+		return models.Photo{}, errors.Wrap(err, "STORAGE_ERROR", "Failed to store photo", http.StatusInternalServerError)
+	}
+	if err := s.store.Put(ctx, photo.ThumbnailKey, &thumbnail, "image/jpeg"); err != nil {
+		s.deleteBlobs(ctx, photo)
+		return models.Photo{}, errors.Wrap(err, "STORAGE_ERROR", "Failed to store thumbnail", http.StatusInternalServerError)
+	}
+
+	// The size check in the filter keeps concurrent uploads under the limit
+	filter := bson.M{"_id": objID, fmt.Sprintf("photos.%d", maxPhotosPerPOI-1): bson.M{"$exists": false}}
+	result, err := s.geoService.collection.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"photos": photo}})
+	if err != nil {
+		s.deleteBlobs(ctx, photo)
+		return models.Photo{}, errors.Wrap(err, "DB_ERROR", "Failed to save photo", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		s.deleteBlobs(ctx, photo)
+		if _, err := s.geoService.GetPOI(ctx, poiID); err != nil {
+			return models.Photo{}, err
+		}
+		return models.Photo{}, errors.NewAPIError("CONFLICT", "POI has too many photos", http.StatusConflict,
+			fmt.Sprintf("a POI can have at most %d photos", maxPhotosPerPOI))
+	}
+	s.reindex(ctx, poiID)
+	return photo, nil
+}
+
+// DeletePhoto removes a photo from a POI. Only the uploader and admins may delete it.
+func (s *PhotoService) DeletePhoto(ctx context.Context, poiID, photoID string) error {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return errors.ErrUnauthorized
+	}
+	role, _ := ctx.Value("role").(string)
+	poi, err := s.geoService.GetPOI(ctx, poiID)
+	if err != nil {
+		return err
+	}
+	var photo *models.Photo
+	for i := range poi.Photos {
+		if poi.Photos[i].ID == photoID {
+			photo = &poi.Photos[i]
+			break
+		}
+	}
+	if photo == nil {
+		return errors.ErrNotFound
+	}
+	if photo.UserID != userID && role != "admin" {
+		return errors.ErrForbidden
+	}
+
+	objID, err := poiObjectID(poiID)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$pull": bson.M{"photos": bson.M{"id": photoID}}}
+	if _, err := s.geoService.collection.UpdateOne(ctx, bson.M{"_id": objID}, update); err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to delete photo", http.StatusInternalServerError)
+	}
+	s.deleteBlobs(ctx, *photo)
+	s.reindex(ctx, poiID)
+	return nil
+}
+
+// deleteBlobs removes the stored files of a photo, failures only leave orphaned files
+func (s *PhotoService) deleteBlobs(ctx context.Context, photo models.Photo) {
+	for _, key := range []string{photo.Key, photo.ThumbnailKey} {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// reindex refreshes the Redis copy of a POI after its photos changed
+func (s *PhotoService) reindex(ctx context.Context, poiID string) {
+	poi, err := s.geoService.GetPOI(ctx, poiID)
+	if err != nil {
+		log.Printf("Failed to reload POI %s: %v", poiID, err)
+		return
+	}
+	// Only the photos changed, so the POI is its own previous version
+	s.geoService.reindexPOI(ctx, poi, &poi)
+}
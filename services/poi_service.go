@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"go-server/hours"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// POIPatch holds the fields of a partial POI update, nil fields are left untouched
+type POIPatch struct {
+	Name         *string          `json:"name"`
+	Description  *string          `json:"description"`
+	Type         *string          `json:"type"`
+	Location     *models.GeoPoint `json:"location"`
+	Tags         *[]string        `json:"tags"`
+	Address      *string          `json:"address"`
+	OpeningHours *string          `json:"opening_hours"`
+	Timezone     *string          `json:"timezone"`
+}
+
+// validatePOI checks that a POI can be stored and indexed
+func validatePOI(poi models.POI) error {
+	if strings.TrimSpace(poi.Name) == "" {
+		return errors.NewAPIError("INVALID_POI", "Invalid POI", http.StatusBadRequest, "name is required")
+	}
+	if poi.Location.Type != "Point" || len(poi.Location.Coordinates) != 2 {
+		return errors.NewAPIError("INVALID_POI", "Invalid POI", http.StatusBadRequest, "location must be a GeoJSON Point")
+	}
+	lon, lat := poi.Location.Coordinates[0], poi.Location.Coordinates[1]
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return errors.NewAPIError("INVALID_POI", "Invalid POI", http.StatusBadRequest, "location coordinates out of range")
+	}
+	if poi.OpeningHours != "" {
+		if _, err := hours.Parse(poi.OpeningHours); err != nil {
+			return errors.NewAPIError("INVALID_POI", "Invalid POI", http.StatusBadRequest, "opening_hours: "+err.Error())
+		}
+	}
+	if poi.Timezone != "" {
+		if _, err := time.LoadLocation(poi.Timezone); err != nil {
+			return errors.NewAPIError("INVALID_POI", "Invalid POI", http.StatusBadRequest, "unknown timezone "+poi.Timezone)
+		}
+	}
+	return nil
+}
+
+// poiObjectID parses a POI ID into a MongoDB ObjectID
+func poiObjectID(id string) (primitive.ObjectID, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, errors.ErrNotFound
+	}
+	return objID, nil
+}
+
+// GetPOI retrieves a single POI from MongoDB
+func (s *GeoService) GetPOI(ctx context.Context, id string) (models.POI, error) {
+	objID, err := poiObjectID(id)
+	if err != nil {
+		return models.POI{}, err
+	}
+	var poi models.POI
+	err = s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&poi)
+	if err == mongo.ErrNoDocuments {
+		return models.POI{}, errors.ErrNotFound
+	}
+	if err != nil {
+		return models.POI{}, errors.Wrap(err, "DB_ERROR", "Failed to get POI", http.StatusInternalServerError)
+	}
+	return poi, nil
+}
+
+// GetPOIsByID retrieves POIs from MongoDB in the order of ids, skipping IDs that don't exist
+func (s *GeoService) GetPOIsByID(ctx context.Context, ids []string) ([]models.POI, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return []models.POI{}, nil
+	}
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to get POIs", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	var found []models.POI
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, errors.Wrap(err, "DB_ERROR", "Failed to decode POIs", http.StatusInternalServerError)
+	}
+
+	byID := make(map[string]models.POI, len(found))
+	for _, poi := range found {
+		byID[poi.ID] = poi
+	}
+	pois := make([]models.POI, 0, len(found))
+	for _, id := range ids {
+		if poi, ok := byID[id]; ok {
+			pois = append(pois, poi)
+		}
+	}
+	return pois, nil
+}
+
+// CreatePOI inserts a new POI into MongoDB and indexes it in the POI store
+func (s *GeoService) CreatePOI(ctx context.Context, poi models.POI) (models.POI, error) {
+	if err := validatePOI(poi); err != nil {
+		return models.POI{}, err
+	}
+	poi.ID = ""
+	poi.RatingAvg, poi.RatingCount = 0, 0
+	poi.Photos = nil
+	assignCells(&poi)
+	if poi.Tags == nil {
+		poi.Tags = []string{}
+	}
+	result, err := s.collection.InsertOne(ctx, poi)
+	if err != nil {
+		return models.POI{}, errors.Wrap(err, "DB_ERROR", "Failed to create POI", http.StatusInternalServerError)
+	}
+	poi.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	s.reindexPOI(ctx, poi, nil)
+	return poi, nil
+}
+
+// UpdatePOI replaces an existing POI in MongoDB and the POI store
+func (s *GeoService) UpdatePOI(ctx context.Context, id string, poi models.POI) (models.POI, error) {
+	objID, err := poiObjectID(id)
+	if err != nil {
+		return models.POI{}, err
+	}
+	if err := validatePOI(poi); err != nil {
+		return models.POI{}, err
+	}
+	// Ratings and photos have their own endpoints and aren't replaced here,
+	// and the external ID ties the POI to its import source
+	existing, err := s.GetPOI(ctx, id)
+	if err != nil {
+		return models.POI{}, err
+	}
+	poi.ID = ""
+	poi.ExternalID = existing.ExternalID
+	poi.RatingAvg, poi.RatingCount = existing.RatingAvg, existing.RatingCount
+	poi.Photos = existing.Photos
+	assignCells(&poi)
+	if poi.Tags == nil {
+		poi.Tags = []string{}
+	}
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"_id": objID}, poi)
+	if err != nil {
+		return models.POI{}, errors.Wrap(err, "DB_ERROR", "Failed to update POI", http.StatusInternalServerError)
+	}
+	if result.MatchedCount == 0 {
+		return models.POI{}, errors.ErrNotFound
+	}
+	poi.ID = id
+	s.reindexPOI(ctx, poi, &existing)
+	return poi, nil
+}
+
+// PatchPOI applies a partial update to an existing POI
+func (s *GeoService) PatchPOI(ctx context.Context, id string, patch POIPatch) (models.POI, error) {
+	poi, err := s.GetPOI(ctx, id)
+	if err != nil {
+		return models.POI{}, err
+	}
+	if patch.Name != nil {
+		poi.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		poi.Description = *patch.Description
+	}
+	if patch.Type != nil {
+		poi.Type = *patch.Type
+	}
+	if patch.Location != nil {
+		poi.Location = *patch.Location
+	}
+	if patch.Tags != nil {
+		poi.Tags = *patch.Tags
+	}
+	if patch.Address != nil {
+		poi.Address = *patch.Address
+	}
+	if patch.OpeningHours != nil {
+		poi.OpeningHours = *patch.OpeningHours
+	}
+	if patch.Timezone != nil {
+		poi.Timezone = *patch.Timezone
+	}
+	return s.UpdatePOI(ctx, id, poi)
+}
+
+// DeletePOI removes a POI from MongoDB and the POI store
+func (s *GeoService) DeletePOI(ctx context.Context, id string) error {
+	objID, err := poiObjectID(id)
+	if err != nil {
+		return err
+	}
+	var previous models.POI
+	err = s.collection.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to delete POI", http.StatusInternalServerError)
+	}
+	s.searchIndex.Remove(id)
+	s.geocoder.Remove(id)
+	s.setClusters(nil)
+	s.nearbyCache.clear()
+	s.invalidateTiles(ctx)
+	// MongoDB is the source of truth, the next sync removes any leftovers
+	if err := s.store.Remove(ctx, id); err != nil {
+		log.Printf("Failed to remove POI %s from the POI store: %v", id, err)
+	}
+	if s.RedisClient == nil {
+		return nil
+	}
+	if _, err := s.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removeSuggestions(ctx, pipe, previous)
+		removeTags(ctx, pipe, previous)
+		return nil
+	}); err != nil {
+		log.Printf("Failed to remove POI %s from Redis: %v", id, err)
+	}
+	return nil
+}
+
+// reindexPOI writes a POI to the POI store and its suggestions and tags to
+// Redis right after it changed in MongoDB. previous is the POI before the
+// change, nil for new POIs.
+func (s *GeoService) reindexPOI(ctx context.Context, poi models.POI, previous *models.POI) {
+	s.searchIndex.Add(poi)
+	s.geocoder.Add(poi)
+	if previous == nil || !samePlacement(*previous, poi) {
+		s.setClusters(nil)
+		s.nearbyCache.clear()
+	}
+	if previous == nil || !sameTileData(*previous, poi) {
+		s.invalidateTiles(ctx)
+	}
+	// MongoDB is the source of truth, the next sync repairs the indexes
+	if err := s.store.Put(ctx, poi); err != nil {
+		log.Printf("Failed to index POI %s in the POI store: %v", poi.Name, err)
+	}
+	if s.RedisClient == nil {
+		return
+	}
+	if _, err := s.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != nil {
+			removeSuggestions(ctx, pipe, *previous)
+			removeTags(ctx, pipe, *previous)
+		}
+		addSuggestions(ctx, pipe, poi)
+		addTags(ctx, pipe, poi)
+		return nil
+	}); err != nil {
+		log.Printf("Failed to index POI %s in Redis: %v", poi.Name, err)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-server/models"
+	"log"
+	"sort"
+	"time"
+)
+
+// POIStore answers the spatial and lookup queries behind the POI endpoints.
+// MongoDB stays the source of truth: stores are kept in step by Put and
+// Remove after every edit, and by Sync on startup and every sync interval.
+type POIStore interface {
+	// Nearby returns every POI within radius meters of a point
+	Nearby(ctx context.Context, lon, lat, radius float64) ([]POIHit, error)
+	// Within returns POIs inside a box that doesn't cross the antimeridian,
+	// nearest to its center first. The result may include some POIs just
+	// outside the box. count caps the result, 0 means no cap.
+	Within(ctx context.Context, box BoundingBox, count int) ([]POIHit, error)
+	// Get loads POIs aligned with ids, with nil entries for unknown IDs
+	Get(ctx context.Context, ids []string) ([]*models.POI, error)
+	// Put adds or replaces a POI
+	Put(ctx context.Context, poi models.POI) error
+	// Remove drops a POI, removing an unknown POI is not an error
+	Remove(ctx context.Context, id string) error
+	// Sync replaces the stored POIs with the given set and reports the changes
+	Sync(ctx context.Context, pois []models.POI) (POISyncResult, error)
+}
+
+// POIHit is the position of a POI matched by a store query
+type POIHit struct {
+	ID       string
+	Lon      float64
+	Lat      float64
+	Distance float64 // Meters from the queried point, Nearby only
+}
+
+// POIStoreKind selects a POIStore implementation, see POI_STORE
+type POIStoreKind string
+
+const (
+	RedisPOIStore  POIStoreKind = "redis"
+	MongoPOIStore  POIStoreKind = "mongo"
+	MemoryPOIStore POIStoreKind = "memory"
+)
+
+// newPOIStore creates the configured store, Redis by default
+func (s *GeoService) newPOIStore(kind POIStoreKind) (POIStore, error) {
+	switch kind {
+	case "", RedisPOIStore:
+		return &RedisStore{client: s.RedisClient}, nil
+	case MongoPOIStore:
+		return s.mongoStore, nil
+	case MemoryPOIStore:
+		return NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unknown POI store %q, expected redis, mongo or memory", kind)
+}
+
+// The Redis circuit opens after this many consecutive failed queries, and
+// Redis is probed again after the retry interval
+const (
+	redisFailureThreshold = 3
+	redisRetryInterval    = 30 * time.Second
+)
+
+// withFallback runs a query against the POI store, or against MongoDB while
+// the Redis circuit is open or when the store query fails. The query is told
+// whether it runs as the fallback, where nothing in Redis may be used.
+func (s *GeoService) withFallback(ctx context.Context, query func(store POIStore, fallback bool) error) error {
+	if s.redisBreaker.Allow() {
+		err := query(s.store, false)
+		// Callers giving up say nothing about Redis
+		if ctx.Err() == nil {
+			s.redisBreaker.Record(err)
+		}
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		log.Printf("POI query failed, falling back to MongoDB: %v", err)
+	}
+	return query(s.mongoStore, true)
+}
+
+// sortHits orders hits by distance, breaking ties by ID so paging is stable
+func sortHits(hits []POIHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Distance != hits[j].Distance {
+			return hits[i].Distance < hits[j].Distance
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-server/models"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Redis keys owned by the POI subsystem. Nothing outside of these keys is
+// touched when syncing, so user locations and cached users survive restarts.
+const (
+	poiGeoKey     = "pois:geo"
+	poiSuggestKey = "pois:suggest"
+	poiKeyPrefix  = "poi:"
+)
+
+// poiKey returns the Redis hash key holding a POI's data
+func poiKey(id string) string {
+	return poiKeyPrefix + id
+}
+
+// indexPOI writes a POI's hash and geo entry
+func indexPOI(ctx context.Context, rdb redis.Cmdable, poi models.POI, poiJSON []byte) {
+	rdb.HSet(ctx, poiKey(poi.ID), "data", poiJSON)
+	addPOIPosition(ctx, rdb, poi)
+}
+
+// addPOIPosition writes a POI's geo entry, which is a no-op when it's already there
+func addPOIPosition(ctx context.Context, rdb redis.Cmdable, poi models.POI) {
+	rdb.GeoAdd(ctx, poiGeoKey, &redis.GeoLocation{
+		Name:      poi.ID,
+		Longitude: poi.Location.Coordinates[0],
+		Latitude:  poi.Location.Coordinates[1],
+	})
+}
+
+// unindexPOI removes every Redis entry held for a POI
+func unindexPOI(ctx context.Context, rdb redis.Cmdable, id string) {
+	rdb.ZRem(ctx, poiGeoKey, id)
+	rdb.Del(ctx, poiKey(id))
+}
+
+// POISyncResult summarises a single sync run of MongoDB into the POI store
+type POISyncResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// SyncPOIs loads every POI from MongoDB into the POI store, which only
+// writes the changes, and rebuilds the Redis suggestion and tag sets when
+// anything changed.
+func (s *GeoService) SyncPOIs(ctx context.Context) (POISyncResult, error) {
+	var result POISyncResult
+
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return result, fmt.Errorf("failed to load POIs from MongoDB: %v", err)
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return result, fmt.Errorf("failed to decode POIs from MongoDB: %v", err)
+	}
+	s.searchIndex.Replace(pois)
+	s.geocoder.Replace(pois)
+
+	valid := pois[:0]
+	for _, poi := range pois {
+		if len(poi.Location.Coordinates) < 2 {
+			log.Printf("Skipping POI %s with invalid location", poi.Name)
+			continue
+		}
+		valid = append(valid, poi)
+	}
+	pois = valid
+	if result, err = s.store.Sync(ctx, pois); err != nil {
+		return result, err
+	}
+
+	// Name and tag changes can't be diffed member by member, so the
+	// autocomplete and tag sets are rebuilt whenever anything changed (or
+	// they don't exist yet)
+	changed := result.Added+result.Updated+result.Removed > 0
+	// Edits on other replicas reach a shared Redis store directly, so its
+	// diff can't tell whether the clusters and nearby cache held in this
+	// process are stale, but the placement of the POIs can
+	moved := s.swapPlacement(placementFingerprint(pois))
+	if changed || moved || !s.hasClusters() {
+		s.setClusters(buildClusters(pois))
+		s.nearbyCache.clear()
+	}
+	if changed {
+		s.invalidateTiles(ctx)
+	}
+	if s.RedisClient == nil {
+		log.Printf("Synced POIs: %d added, %d updated, %d removed, %d unchanged",
+			result.Added, result.Updated, result.Removed, result.Unchanged)
+		return result, nil
+	}
+	exists, err := s.RedisClient.Exists(ctx, poiSuggestKey).Result()
+	if err != nil {
+		return result, fmt.Errorf("failed to check %s: %v", poiSuggestKey, err)
+	}
+	if exists == 0 || changed {
+		if err := s.rebuildSuggestions(ctx, pois); err != nil {
+			return result, fmt.Errorf("failed to rebuild %s: %v", poiSuggestKey, err)
+		}
+	}
+	exists, err = s.RedisClient.Exists(ctx, poiTagsKey).Result()
+	if err != nil {
+		return result, fmt.Errorf("failed to check %s: %v", poiTagsKey, err)
+	}
+	if exists == 0 || changed {
+		if err := s.rebuildTags(ctx, pois); err != nil {
+			return result, fmt.Errorf("failed to rebuild tag sets: %v", err)
+		}
+	}
+
+	log.Printf("Synced POIs: %d added, %d updated, %d removed, %d unchanged",
+		result.Added, result.Updated, result.Removed, result.Unchanged)
+	return result, nil
+}
+
+// DropLegacyPOIHashes deletes the un-namespaced <id> hashes that versions
+// before poi:<id> wrote for every POI, a one-off migration run with
+// `go-server migrate-legacy-poi-keys`. Only hashes named after a POI in
+// MongoDB and holding a "data" field are deleted.
+func (s *GeoService) DropLegacyPOIHashes(ctx context.Context) (int, error) {
+	if s.RedisClient == nil {
+		return 0, ErrRedisUnavailable
+	}
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load POIs from MongoDB: %v", err)
+	}
+	defer cursor.Close(ctx)
+	var pois []models.POI
+	if err := cursor.All(ctx, &pois); err != nil {
+		return 0, fmt.Errorf("failed to decode POIs from MongoDB: %v", err)
+	}
+
+	legacy := make([]*redis.BoolCmd, len(pois))
+	pipe := s.RedisClient.Pipeline()
+	for i, poi := range pois {
+		legacy[i] = pipe.HExists(ctx, poi.ID, "data")
+	}
+	// Keys of another type answer WRONGTYPE and aren't ours
+	if _, err := pipe.Exec(ctx); err != nil && !redis.HasErrorPrefix(err, "WRONGTYPE") {
+		return 0, fmt.Errorf("failed to read legacy POI hashes: %v", err)
+	}
+	dropped := 0
+	pipe = s.RedisClient.Pipeline()
+	for i, poi := range pois {
+		if legacy[i].Err() == nil && legacy[i].Val() {
+			pipe.Del(ctx, poi.ID)
+			dropped++
+		}
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete legacy POI hashes: %v", err)
+	}
+	return dropped, nil
+}
+
+// StartPOISync periodically syncs POIs from MongoDB into the POI store until ctx is done
+func (s *GeoService) StartPOISync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.SyncPOIs(ctx); err != nil {
+					log.Printf("POI sync failed: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-server/models"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps POI positions in the pois:geo set and POI data in
+// poi:<id> hashes
+type RedisStore struct {
+	client *redis.Client
+}
+
+func (s *RedisStore) Nearby(ctx context.Context, lon, lat, radius float64) ([]POIHit, error) {
+	// Fetch every member in range, GeoRadius can't offset so paging and
+	// filtering happen on the caller's side
+	geoResults, err := s.client.GeoRadius(ctx, poiGeoKey, lon, lat, &redis.GeoRadiusQuery{
+		Radius:    radius,
+		Unit:      "m",
+		WithDist:  true,
+		WithCoord: true,
+		Sort:      "ASC",
+	}).Result()
+	if err != nil {
+		log.Printf("Redis GeoRadius error: %v", err)
+		return nil, err
+	}
+	hits := make([]POIHit, len(geoResults))
+	for i, geoResult := range geoResults {
+		hits[i] = POIHit{ID: geoResult.Name, Lon: geoResult.Longitude, Lat: geoResult.Latitude, Distance: geoResult.Dist}
+	}
+	return hits, nil
+}
+
+func (s *RedisStore) Within(ctx context.Context, box BoundingBox, count int) ([]POIHit, error) {
+	geoResults, err := s.client.GeoSearchLocation(ctx, poiGeoKey, box.searchQuery(count)).Result()
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]POIHit, len(geoResults))
+	for i, geoResult := range geoResults {
+		hits[i] = POIHit{ID: geoResult.Name, Lon: geoResult.Longitude, Lat: geoResult.Latitude}
+	}
+	return hits, nil
+}
+
+// Get fetches POI data in one round trip
+func (s *RedisStore) Get(ctx context.Context, ids []string) ([]*models.POI, error) {
+	cmds := make([]*redis.StringCmd, len(ids))
+	pipe := s.client.Pipeline()
+	for i, id := range ids {
+		cmds[i] = pipe.HGet(ctx, poiKey(id), "data")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("Redis pipeline error: %v", err)
+		return nil, err
+	}
+
+	pois := make([]*models.POI, len(ids))
+	for i, id := range ids {
+		poiJSON, err := cmds[i].Result()
+		if err != nil {
+			log.Printf("Redis Get error for POI %s: %v", id, err)
+			continue
+		}
+		var poi models.POI
+		if err := json.Unmarshal([]byte(poiJSON), &poi); err != nil {
+			log.Printf("Failed to unmarshal POI %s: %v", id, err)
+			continue
+		}
+		pois[i] = &poi
+	}
+	return pois, nil
+}
+
+func (s *RedisStore) Put(ctx context.Context, poi models.POI) error {
+	poiJSON, err := json.Marshal(poi)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		indexPOI(ctx, pipe, poi, poiJSON)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Remove(ctx context.Context, id string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		unindexPOI(ctx, pipe, id)
+		return nil
+	})
+	return err
+}
+
+// Sync diffs the POIs against Redis, writing only changed POIs and removing
+// the ones that no longer exist. Keys outside of the POI keys are left alone.
+func (s *RedisStore) Sync(ctx context.Context, pois []models.POI) (POISyncResult, error) {
+	var result POISyncResult
+
+	// Fetch the currently indexed POI IDs
+	indexed, err := s.client.ZRange(ctx, poiGeoKey, 0, -1).Result()
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %v", poiGeoKey, err)
+	}
+	stale := make(map[string]bool, len(indexed))
+	for _, id := range indexed {
+		stale[id] = true
+	}
+
+	// Fetch the stored data of every POI in one round trip
+	existing := make([]*redis.StringCmd, len(pois))
+	pipe := s.client.Pipeline()
+	for i, poi := range pois {
+		existing[i] = pipe.HGet(ctx, poiKey(poi.ID), "data")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return result, fmt.Errorf("failed to read POI hashes: %v", err)
+	}
+
+	pipe = s.client.Pipeline()
+	for i, poi := range pois {
+		delete(stale, poi.ID)
+		poiJSON, err := json.Marshal(poi)
+		if err != nil {
+			log.Printf("Failed to marshal POI %s: %v", poi.Name, err)
+			continue
+		}
+		current, err := existing[i].Result()
+		switch {
+		case err == redis.Nil:
+			result.Added++
+		case err != nil:
+			log.Printf("Failed to read POI %s from Redis: %v", poi.Name, err)
+			continue
+		case current == string(poiJSON):
+			result.Unchanged++
+			// Repair the geo entry in case only that write was lost
+			addPOIPosition(ctx, pipe, poi)
+			continue
+		default:
+			result.Updated++
+		}
+		indexPOI(ctx, pipe, poi, poiJSON)
+	}
+	for id := range stale {
+		unindexPOI(ctx, pipe, id)
+		result.Removed++
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return result, fmt.Errorf("failed to write POIs to Redis: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"go-server/models"
+	"go-server/utils/errors"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultReviewLimit  = 20
+	maxReviewLimit      = 100
+	maxReviewTextLength = 2000
+)
+
+type ReviewService struct {
+	collection  *mongo.Collection
+	userService *UserService
+	geoService  *GeoService
+}
+
+// ReviewInput is the body of a review create or edit
+type ReviewInput struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+func NewReviewService(userService *UserService, geoService *GeoService) *ReviewService {
+	collection := userService.collection.Database().Collection("reviews")
+
+	indexModels := []mongo.IndexModel{
+		// One review per user per POI
+		{
+			Keys:    bson.D{{Key: "poi_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "poi_id", Value: 1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexModels); err != nil {
+		log.Printf("Failed to create indexes on reviews: %v", err)
+	}
+
+	return &ReviewService{
+		collection:  collection,
+		userService: userService,
+		geoService:  geoService,
+	}
+}
+
+// SaveReview creates the current user's review of a POI or replaces their
+// existing one. It reports whether the review was created.
+func (s *ReviewService) SaveReview(ctx context.Context, poiID string, input ReviewInput) (models.Review, bool, error) {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return models.Review{}, false, errors.ErrUnauthorized
+	}
+	input.Text = strings.TrimSpace(input.Text)
+	if input.Rating < 1 || input.Rating > 5 {
+		return models.Review{}, false, errors.NewAPIError("INVALID_REVIEW", "Invalid review", http.StatusBadRequest, "rating must be between 1 and 5")
+	}
+	if len(input.Text) > maxReviewTextLength {
+		return models.Review{}, false, errors.NewAPIError("INVALID_REVIEW", "Invalid review", http.StatusBadRequest, "text is too long")
+	}
+	user, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		return models.Review{}, false, errors.ErrNotFound
+	}
+	if _, err := s.geoService.GetPOI(ctx, poiID); err != nil {
+		return models.Review{}, false, err
+	}
+
+	now := time.Now().UTC()
+	filter := bson.M{"poi_id": poiID, "user_id": userID}
+	update := bson.M{
+		"$set": bson.M{
+			"username":   user.Username,
+			"rating":     input.Rating,
+			"text":       input.Text,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	result, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return models.Review{}, false, errors.Wrap(err, "DB_ERROR", "Failed to save review", http.StatusInternalServerError)
+	}
+	var review models.Review
+	if err := s.collection.FindOne(ctx, filter).Decode(&review); err != nil {
+		return models.Review{}, false, errors.Wrap(err, "DB_ERROR", "Failed to get review", http.StatusInternalServerError)
+	}
+	if err := s.updateRating(ctx, poiID); err != nil {
+		return models.Review{}, false, err
+	}
+	return review, result.UpsertedID != nil, nil
+}
+
+// DeleteReview removes the current user's review of a POI
+func (s *ReviewService) DeleteReview(ctx context.Context, poiID string) error {
+	userID, ok := ctx.Value("userID").(string)
+	if !ok || userID == "" {
+		return errors.ErrUnauthorized
+	}
+	result, err := s.collection.DeleteOne(ctx, bson.M{"poi_id": poiID, "user_id": userID})
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to delete review", http.StatusInternalServerError)
+	}
+	if result.DeletedCount == 0 {
+		return errors.ErrNotFound
+	}
+	return s.updateRating(ctx, poiID)
+}
+
+// GetPOIReviews returns a page of the reviews of a POI, most recently
+// updated first, and the cursor of the next page ("" on the last page).
+// Ties on updated_at are broken by ID so no review is skipped between pages.
+func (s *ReviewService) GetPOIReviews(ctx context.Context, poiID string, cursor string, limit int) ([]models.Review, string, error) {
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = defaultReviewLimit
+	}
+	limit = min(limit, maxReviewLimit)
+	filter := bson.M{"poi_id": poiID}
+	if after != nil {
+		lastID, err := primitive.ObjectIDFromHex(after.LastID)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		filter["$or"] = bson.A{
+			bson.M{"updated_at": bson.M{"$lt": after.Time}},
+			bson.M{"updated_at": after.Time, "_id": bson.M{"$lt": lastID}},
+		}
+	}
+
+	// One extra review tells whether there is a next page
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+	dbCursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "DB_ERROR", "Failed to get reviews", http.StatusInternalServerError)
+	}
+	defer dbCursor.Close(ctx)
+	reviews := []models.Review{}
+	if err := dbCursor.All(ctx, &reviews); err != nil {
+		return nil, "", errors.Wrap(err, "DB_ERROR", "Failed to decode reviews", http.StatusInternalServerError)
+	}
+	nextCursor := ""
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+		last := reviews[limit-1]
+		nextCursor = encodeTimeCursor(timeCursor{Time: last.UpdatedAt, LastID: last.ID})
+	}
+	return reviews, nextCursor, nil
+}
+
+// updateRating recomputes a POI's average rating and review count from its
+// reviews and stores them on the POI document and in Redis. Recomputing
+// rather than adjusting keeps the aggregate right under concurrent writes.
+func (s *ReviewService) updateRating(ctx context.Context, poiID string) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"poi_id": poiID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"avg":   bson.M{"$avg": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to aggregate ratings", http.StatusInternalServerError)
+	}
+	defer cursor.Close(ctx)
+	var stats []struct {
+		Avg   float64 `bson:"avg"`
+		Count int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to decode ratings", http.StatusInternalServerError)
+	}
+
+	objID, err := poiObjectID(poiID)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$unset": bson.M{"rating_avg": "", "rating_count": ""}}
+	if len(stats) > 0 && stats[0].Count > 0 {
+		update = bson.M{"$set": bson.M{
+			"rating_avg":   math.Round(stats[0].Avg*100) / 100,
+			"rating_count": stats[0].Count,
+		}}
+	}
+	if _, err := s.geoService.collection.UpdateOne(ctx, bson.M{"_id": objID}, update); err != nil {
+		return errors.Wrap(err, "DB_ERROR", "Failed to update POI rating", http.StatusInternalServerError)
+	}
+
+	poi, err := s.geoService.GetPOI(ctx, poiID)
+	if err != nil {
+		// The POI was deleted meanwhile, nothing to index
+		return nil
+	}
+	// Only the rating changed, so the POI is its own previous version
+	s.geoService.reindexPOI(ctx, poi, &poi)
+	return nil
+}
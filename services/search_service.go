@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"go-server/models"
+	"go-server/search"
+	"go-server/utils/errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchResult is a POI with its relevance score
+type SearchResult struct {
+	models.POI
+	Score float64 `json:"score"`
+}
+
+// SearchPOIsQuery describes a full-text POI search. Near, when set, boosts
+// POIs close to that point.
+type SearchPOIsQuery struct {
+	Text  string
+	Type  string
+	Near  *models.GeoPoint
+	Limit int
+}
+
+// SearchPOIs ranks POIs by how well their name, tags, address and description match the query
+func (s *GeoService) SearchPOIs(ctx context.Context, query SearchPOIsQuery) ([]SearchResult, error) {
+	if strings.TrimSpace(query.Text) == "" {
+		return nil, errors.NewAPIError("INVALID_QUERY", "Search query is required", http.StatusBadRequest)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	hits := s.searchIndex.Search(query.Text, search.Options{Limit: limit, Type: query.Type, Near: query.Near})
+	results := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = SearchResult{POI: hit.POI, Score: hit.Score}
+	}
+	return results, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"go-server/geometry"
+	"go-server/models"
+	"go-server/search"
+	"go-server/utils/errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 25
+	// suggestCandidates caps how many lexical matches are ranked per query
+	// without a location. With one the nearest matches can be anywhere in
+	// lexical order, so more are ranked, but still a bounded number.
+	suggestCandidates     = 200
+	suggestNearCandidates = suggestCandidates * 4
+	// Names are indexed from each of their first few words, a few words deep
+	suggestMaxStartWords  = 8
+	suggestMaxPhraseWords = 6
+)
+
+// Suggestion is an autocomplete entry for a POI name
+type Suggestion struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	DistanceM *float64 `json:"distance_m,omitempty"`
+}
+
+// suggestPhrase normalizes text for lexical prefix matching
+func suggestPhrase(text string) string {
+	words := strings.FieldsFunc(search.Normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// suggestMembers returns the pois:suggest entries for a POI. There is one
+// entry per starting word so that "mosque" finds "Sultan Mosque", each
+// formatted as "<phrase>\x00<id>\x00<word index>".
+func suggestMembers(poi models.POI) []string {
+	words := strings.Fields(suggestPhrase(poi.Name))
+	var members []string
+	for i := 0; i < len(words) && i < suggestMaxStartWords; i++ {
+		phrase := strings.Join(words[i:min(i+suggestMaxPhraseWords, len(words))], " ")
+		members = append(members, phrase+"\x00"+poi.ID+"\x00"+strconv.Itoa(i))
+	}
+	return members
+}
+
+// addSuggestions adds a POI's entries to pois:suggest
+func addSuggestions(ctx context.Context, rdb redis.Cmdable, poi models.POI) {
+	for _, member := range suggestMembers(poi) {
+		rdb.ZAdd(ctx, poiSuggestKey, redis.Z{Member: member})
+	}
+}
+
+// removeSuggestions removes a POI's entries from pois:suggest
+func removeSuggestions(ctx context.Context, rdb redis.Cmdable, poi models.POI) {
+	for _, member := range suggestMembers(poi) {
+		rdb.ZRem(ctx, poiSuggestKey, member)
+	}
+}
+
+// rebuildSuggestions replaces pois:suggest with entries for the given POIs.
+// The set is built under a temporary key and renamed so readers never see it half built.
+func (s *GeoService) rebuildSuggestions(ctx context.Context, pois []models.POI) error {
+	tmpKey := poiSuggestKey + ":tmp"
+	pipe := s.RedisClient.Pipeline()
+	pipe.Del(ctx, tmpKey)
+	count := 0
+	for _, poi := range pois {
+		for _, member := range suggestMembers(poi) {
+			pipe.ZAdd(ctx, tmpKey, redis.Z{Member: member})
+			count++
+		}
+	}
+	if count > 0 {
+		pipe.Rename(ctx, tmpKey, poiSuggestKey)
+	} else {
+		pipe.Del(ctx, poiSuggestKey)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SuggestPOIs returns POI names starting with prefix at any word. Matches at
+// the start of the name rank first and, when near is set, closer POIs rank
+// higher. Only the first suggestCandidates matches in lexical order are
+// ranked, or suggestNearCandidates when near is set.
+func (s *GeoService) SuggestPOIs(ctx context.Context, prefix string, near *models.GeoPoint, limit int) ([]Suggestion, error) {
+	phrase := suggestPhrase(prefix)
+	if phrase == "" {
+		return nil, errors.NewAPIError("INVALID_PREFIX", "Prefix is required", http.StatusBadRequest)
+	}
+	if s.RedisClient == nil {
+		return nil, ErrRedisUnavailable
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	limit = min(limit, maxSuggestLimit)
+
+	count := int64(suggestCandidates)
+	if near != nil {
+		count = suggestNearCandidates
+	}
+	members, err := s.RedisClient.ZRangeByLex(ctx, poiSuggestKey, &redis.ZRangeBy{
+		Min:   "[" + phrase,
+		Max:   "[" + phrase + "\xff",
+		Count: count,
+	}).Result()
+	if err != nil {
+		log.Printf("Redis ZRangeByLex error: %v", err)
+		return nil, err
+	}
+
+	// Keep the earliest matching word per POI
+	wordIndex := map[string]int{}
+	var ids []string
+	for _, member := range members {
+		parts := strings.Split(member, "\x00")
+		if len(parts) != 3 {
+			continue
+		}
+		id := parts[1]
+		index, _ := strconv.Atoi(parts[2])
+		if current, ok := wordIndex[id]; !ok {
+			ids = append(ids, id)
+			wordIndex[id] = index
+		} else if index < current {
+			wordIndex[id] = index
+		}
+	}
+	pois, err := s.store.Get(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	type ranked struct {
+		suggestion Suggestion
+		score      float64
+	}
+	var candidates []ranked
+	for _, poi := range pois {
+		// Entries of deleted POIs disappear at the next sync
+		if poi == nil {
+			continue
+		}
+		suggestion := Suggestion{ID: poi.ID, Name: poi.Name, Type: poi.Type}
+		// Lower is better: later word matches and longer distances both cost
+		score := float64(1 + wordIndex[poi.ID])
+		if near != nil && len(near.Coordinates) == 2 && len(poi.Location.Coordinates) == 2 {
+			distance := math.Round(geometry.Distance(near.Coordinates[0], near.Coordinates[1], poi.Location.Coordinates[0], poi.Location.Coordinates[1]))
+			suggestion.DistanceM = &distance
+			score *= 1 + distance/1000
+		}
+		candidates = append(candidates, ranked{suggestion: suggestion, score: score})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return len(candidates[i].suggestion.Name) < len(candidates[j].suggestion.Name)
+	})
+
+	suggestions := make([]Suggestion, 0, min(limit, len(candidates)))
+	for _, candidate := range candidates[:min(limit, len(candidates))] {
+		suggestions = append(suggestions, candidate.suggestion)
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"go-server/models"
+	"go-server/search"
+	"go-server/utils/errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Each tag has a set of the IDs of the POIs carrying it, and pois:tags lists
+// the tags in use so the sets can be rebuilt without scanning the keyspace
+const (
+	poiTagsKey      = "pois:tags"
+	poiTagKeyPrefix = "pois:tag:"
+)
+
+// TagsMode says whether POIs need all or any of the requested tags
+type TagsMode string
+
+const (
+	TagsModeAll TagsMode = "all"
+	TagsModeAny TagsMode = "any"
+)
+
+// ParseTagsMode parses a tags_mode query parameter, defaulting to all
+func ParseTagsMode(s string) (TagsMode, error) {
+	switch mode := TagsMode(s); mode {
+	case "":
+		return TagsModeAll, nil
+	case TagsModeAll, TagsModeAny:
+		return mode, nil
+	}
+	return "", errors.NewAPIError("INVALID_TAGS_MODE", "Invalid tags mode", http.StatusBadRequest, "expected all or any")
+}
+
+// NormalizeTag folds case, diacritics and separators so "Outdoor Seating"
+// matches "outdoor_seating"
+func NormalizeTag(tag string) string {
+	words := strings.FieldsFunc(search.Normalize(tag), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "_")
+}
+
+// ParseTags splits a comma separated tag list into normalized tags
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func poiTagKey(tag string) string {
+	return poiTagKeyPrefix + tag
+}
+
+// poiTags returns the distinct normalized tags of a POI
+func poiTags(poi models.POI) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, tag := range poi.Tags {
+		if tag = NormalizeTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// addTags adds a POI to the sets of its tags
+func addTags(ctx context.Context, rdb redis.Cmdable, poi models.POI) {
+	for _, tag := range poiTags(poi) {
+		rdb.SAdd(ctx, poiTagKey(tag), poi.ID)
+		rdb.SAdd(ctx, poiTagsKey, tag)
+	}
+}
+
+// removeTags removes a POI from the sets of its tags. Emptied sets are
+// deleted by Redis, their pois:tags entries go at the next rebuild.
+func removeTags(ctx context.Context, rdb redis.Cmdable, poi models.POI) {
+	for _, tag := range poiTags(poi) {
+		rdb.SRem(ctx, poiTagKey(tag), poi.ID)
+	}
+}
+
+// rebuildTags replaces every tag set with sets built from the given POIs, in
+// one transaction so readers never see them half built
+func (s *GeoService) rebuildTags(ctx context.Context, pois []models.POI) error {
+	oldTags, err := s.RedisClient.SMembers(ctx, poiTagsKey).Result()
+	if err != nil {
+		return err
+	}
+	_, err = s.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range oldTags {
+			pipe.Del(ctx, poiTagKey(tag))
+		}
+		pipe.Del(ctx, poiTagsKey)
+		for _, poi := range pois {
+			addTags(ctx, pipe, poi)
+		}
+		return nil
+	})
+	return err
+}
+
+// TagFilter selects POIs by their tags
+type TagFilter struct {
+	Tags    []string // Normalized tags, see ParseTags
+	Mode    TagsMode
+	Exclude []string
+}
+
+func (f TagFilter) empty() bool {
+	return len(f.Tags) == 0 && len(f.Exclude) == 0
+}
+
+// matchesPOI checks a POI's own tags against the filter, for when the
+// Redis tag sets can't be used
+func (f TagFilter) matchesPOI(poi models.POI) bool {
+	if f.empty() {
+		return true
+	}
+	tags := map[string]bool{}
+	for _, tag := range poiTags(poi) {
+		tags[tag] = true
+	}
+	for _, tag := range f.Exclude {
+		if tags[tag] {
+			return false
+		}
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	matched := 0
+	for _, tag := range f.Tags {
+		if tags[tag] {
+			matched++
+		}
+	}
+	if f.Mode == TagsModeAny {
+		return matched > 0
+	}
+	return matched == len(f.Tags)
+}
+
+// filterByTags keeps the store hits whose POIs pass a tag filter. Membership
+// of every result is checked against the tag sets in one round trip, so no
+// POI data has to be loaded.
+func (s *GeoService) filterByTags(ctx context.Context, hits []POIHit, filter TagFilter) ([]POIHit, error) {
+	if filter.empty() || len(hits) == 0 {
+		return hits, nil
+	}
+	ids := make([]any, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	pipe := s.RedisClient.Pipeline()
+	included := make([]*redis.BoolSliceCmd, len(filter.Tags))
+	for i, tag := range filter.Tags {
+		included[i] = pipe.SMIsMember(ctx, poiTagKey(tag), ids...)
+	}
+	excluded := make([]*redis.BoolSliceCmd, len(filter.Exclude))
+	for i, tag := range filter.Exclude {
+		excluded[i] = pipe.SMIsMember(ctx, poiTagKey(tag), ids...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	kept := hits[:0]
+	for i, hit := range hits {
+		keep := true
+		if len(included) > 0 {
+			matched := 0
+			for _, cmd := range included {
+				if cmd.Val()[i] {
+					matched++
+				}
+			}
+			if filter.Mode == TagsModeAny {
+				keep = matched > 0
+			} else {
+				keep = matched == len(included)
+			}
+		}
+		for _, cmd := range excluded {
+			if cmd.Val()[i] {
+				keep = false
+			}
+		}
+		if keep {
+			kept = append(kept, hit)
+		}
+	}
+	return kept, nil
+}
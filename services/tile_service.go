@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-server/models"
+	"go-server/mvt"
+	"go-server/utils/errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MaxTileZoom is the deepest zoom level tiles are served for
+	MaxTileZoom = 22
+	// tileBuffer is how far past its edges a tile includes POIs, in tile
+	// units, so markers on the edge aren't cut off
+	tileBuffer = 64
+	// Cached tiles are keyed by a version that is bumped whenever POIs
+	// change, so old tiles are never served and simply expire
+	poiTileVersionKey = "tiles:pois:version"
+	poiTileTTL        = time.Hour
+)
+
+// poiTileKey returns the Redis key of a cached tile
+func poiTileKey(version int64, z, x, y int) string {
+	return fmt.Sprintf("tiles:pois:%d:%d/%d/%d", version, z, x, y)
+}
+
+// GetPOITile returns tile z/x/y as a Mapbox Vector Tile with a "pois" layer
+// holding a point per POI, with id, name and type attributes. Tiles are
+// cached in Redis, a Redis failure or running without Redis only skips the
+// cache.
+func (s *GeoService) GetPOITile(ctx context.Context, z, x, y int) ([]byte, error) {
+	if z < 0 || z > MaxTileZoom || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return nil, errors.NewAPIError("INVALID_TILE", "Invalid tile", http.StatusBadRequest,
+			fmt.Sprintf("zoom must be 0 to %d and x, y below 2^zoom", MaxTileZoom))
+	}
+	if s.RedisClient == nil {
+		return s.renderPOITile(ctx, z, x, y)
+	}
+
+	version, err := s.RedisClient.Get(ctx, poiTileVersionKey).Int64()
+	cacheable := err == nil || err == redis.Nil
+	if cacheable {
+		tile, err := s.RedisClient.Get(ctx, poiTileKey(version, z, x, y)).Bytes()
+		if err == nil {
+			return tile, nil
+		}
+		if err != redis.Nil {
+			log.Printf("Failed to read tile %d/%d/%d from Redis: %v", z, x, y, err)
+		}
+	} else {
+		log.Printf("Failed to read tile version from Redis: %v", err)
+	}
+
+	tile, err := s.renderPOITile(ctx, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		if err := s.RedisClient.Set(ctx, poiTileKey(version, z, x, y), tile, poiTileTTL).Err(); err != nil {
+			log.Printf("Failed to cache tile %d/%d/%d in Redis: %v", z, x, y, err)
+		}
+	}
+	return tile, nil
+}
+
+func (s *GeoService) renderPOITile(ctx context.Context, z, x, y int) ([]byte, error) {
+	minLon, minLat, maxLon, maxLat := mvt.TileBounds(z, x, y, mvt.DefaultExtent, tileBuffer)
+	box := BoundingBox{MinLon: minLon, MinLat: minLat, MaxLon: maxLon, MaxLat: maxLat}
+	var pois []models.POI
+	err := s.withFallback(ctx, func(store POIStore, fallback bool) error {
+		hits, _, err := searchBox(ctx, store, box, 0)
+		if err != nil {
+			return err
+		}
+		pois, err = loadMatchingPOIs(ctx, store, hits, "", len(hits))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	layer := mvt.NewLayer("pois", mvt.DefaultExtent)
+	for _, poi := range pois {
+		px, py := mvt.Project(poi.Location.Coordinates[0], poi.Location.Coordinates[1], z, x, y, mvt.DefaultExtent)
+		layer.AddPoint(px, py, []mvt.Property{
+			{Key: "id", Value: poi.ID},
+			{Key: "name", Value: poi.Name},
+			{Key: "type", Value: poi.Type},
+		})
+	}
+	return mvt.Encode(layer), nil
+}
+
+// invalidateTiles makes every cached tile stale
+func (s *GeoService) invalidateTiles(ctx context.Context) {
+	if s.RedisClient == nil {
+		return
+	}
+	if err := s.RedisClient.Incr(ctx, poiTileVersionKey).Err(); err != nil {
+		log.Printf("Failed to invalidate cached tiles: %v", err)
+	}
+}
+
+// sameTileData reports whether two versions of a POI render the same in tiles
+func sameTileData(a, b models.POI) bool {
+	return a.Name == b.Name && samePlacement(a, b)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"go-server/geometry"
+	"go-server/utils/errors"
+	"math"
+	"net/http"
+)
+
+// DistanceUnit is the unit radii are given in and distances are returned in
+type DistanceUnit string
+
+const (
+	Meters     DistanceUnit = "m"
+	Kilometers DistanceUnit = "km"
+	Miles      DistanceUnit = "mi"
+	Feet       DistanceUnit = "ft"
+)
+
+var metersPerUnit = map[DistanceUnit]float64{
+	Meters:     1,
+	Kilometers: 1000,
+	Miles:      1609.344,
+	Feet:       0.3048,
+}
+
+// ParseDistanceUnit validates a units parameter, defaulting to meters
+func ParseDistanceUnit(s string) (DistanceUnit, error) {
+	if s == "" {
+		return Meters, nil
+	}
+	unit := DistanceUnit(s)
+	if _, ok := metersPerUnit[unit]; !ok {
+		return "", errors.NewAPIError("INVALID_UNITS", "Invalid units", http.StatusBadRequest, "units must be one of m, km, mi, ft")
+	}
+	return unit, nil
+}
+
+// ToMeters converts a value in this unit to meters
+func (u DistanceUnit) ToMeters(v float64) float64 {
+	return v * metersPerUnit[u]
+}
+
+// FromMeters converts meters to this unit, rounded to 3 decimals
+func (u DistanceUnit) FromMeters(m float64) float64 {
+	return math.Round(m/metersPerUnit[u]*1000) / 1000
+}
+
+// Direction describes where a result lies relative to the queried point
+type Direction struct {
+	DistanceM  float64 `json:"distance_m"`
+	Distance   float64 `json:"distance"`
+	BearingDeg float64 `json:"bearing_deg"`
+	Compass    string  `json:"compass"`
+}
+
+// newDirection computes the direction from a query point to a result
+func newDirection(fromLon, fromLat, toLon, toLat, distanceM float64, unit DistanceUnit) Direction {
+	bearing := geometry.Bearing(fromLon, fromLat, toLon, toLat)
+	return Direction{
+		DistanceM:  math.Round(distanceM*10) / 10,
+		Distance:   unit.FromMeters(distanceM),
+		BearingDeg: math.Round(bearing*10) / 10,
+		Compass:    geometry.Compass(bearing),
+	}
+}
@@ -15,21 +15,30 @@ import (
 	"time"
 )
 
+const (
+	// locationTTL is how long a location ping counts as the user's position
+	locationTTL = 5 * time.Minute
+	// userPingsKey scores users by the Unix time of their last location ping,
+	// since users:geo only expires as a whole
+	userPingsKey = "users:pinged"
+)
+
 type UserService struct {
 	collection  *mongo.Collection
 	redisClient *redis.Client
+	geoService  *GeoService // POI lookups for favorites
 	jwtSecret   string
 }
 
 type NearbyUsers struct {
 	Username string  `json:"username"`
-	UserID   string  `json:"user_id"`            // Public ID of the user
-	Distance float64 `json:"distance,omitempty"` // Optional, can be used to return distance from the queried point
-	Lat      float64 `json:"lat,omitempty"`      // Optional, can be used to return user's last known latitude
-	Lon      float64 `json:"lon,omitempty"`      // Optional, can be used to return user's last known longitude
+	UserID   string  `json:"user_id"`       // Public ID of the user
+	Lat      float64 `json:"lat,omitempty"` // Optional, can be used to return user's last known latitude
+	Lon      float64 `json:"lon,omitempty"` // Optional, can be used to return user's last known longitude
+	Direction
 }
 
-func NewUserService(redisClient *redis.Client, jwtSecret string) *UserService {
+func NewUserService(geoService *GeoService, jwtSecret string) *UserService {
 	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
 	if err != nil {
 		log.Printf("MongoDB connection failed, user persistence disabled: %v", err)
@@ -48,7 +57,8 @@ func NewUserService(redisClient *redis.Client, jwtSecret string) *UserService {
 
 	return &UserService{
 		collection:  collection,
-		redisClient: redisClient,
+		redisClient: geoService.RedisClient,
+		geoService:  geoService,
 		jwtSecret:   jwtSecret,
 	}
 }
@@ -58,19 +68,24 @@ func (s *UserService) GetUser(ctx context.Context, userID string) (models.User,
 	var user models.User
 
 	// Check Redis first
-	userJSON, err := s.redisClient.Get(ctx, "user:"+userID).Result()
-	if err == nil {
-		if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
-			log.Printf("Failed to unmarshal user %s: %v", userID, err)
-		} else {
-			return user, nil
+	if s.redisClient != nil {
+		userJSON, err := s.redisClient.Get(ctx, "user:"+userID).Result()
+		if err == nil {
+			if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
+				log.Printf("Failed to unmarshal user %s: %v", userID, err)
+			} else {
+				return user, nil
+			}
 		}
 	}
 
-	err = s.collection.FindOne(ctx, bson.M{"public_id": bson.M{"$eq": userID}}).Decode(&user)
+	err := s.collection.FindOne(ctx, bson.M{"public_id": bson.M{"$eq": userID}}).Decode(&user)
 	if err != nil {
 		return models.User{}, err
 	}
+	if s.redisClient == nil {
+		return user, nil
+	}
 
 	// Cache in Redis
 	userJSONBytes, err := json.Marshal(user)
@@ -108,12 +123,15 @@ func (s *UserService) UserLocationPing(ctx context.Context, lat, lon float64) er
 	// if err != nil {
 	// 	return fmt.Errorf("invalid userID: %v", err)
 	// }
+	geohash, hexCell := locationCells(lon, lat)
 	update := bson.M{
 		"$set": bson.M{
 			"lastLocation": bson.M{
 				"type":        "Point",
 				"coordinates": []float64{lon, lat},
 			},
+			"last_geohash":  geohash,
+			"last_hex_cell": hexCell,
 		},
 	}
 	_, err = s.collection.UpdateOne(ctx, bson.M{"public_id": userID}, update)
@@ -122,17 +140,23 @@ func (s *UserService) UserLocationPing(ctx context.Context, lat, lon float64) er
 		return err
 	}
 
+	// Without Redis only the last location in MongoDB is kept
+	if s.redisClient == nil {
+		return nil
+	}
+
 	// Update Redis with TTL (e.g., 5 minutes)
 	user, err := s.GetUser(ctx, userID)
 	if err != nil {
 		return err
 	}
 	user.LastLocation = models.GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+	user.LastGeohash, user.LastHexCell = geohash, hexCell
 	userJSON, err := json.Marshal(user)
 	if err != nil {
 		return err
 	}
-	ttl := 5 * time.Minute
+	ttl := locationTTL
 	err = s.redisClient.Set(ctx, "user:"+user.PublicID, userJSON, ttl).Err()
 	if err != nil {
 		log.Printf("Failed to update Redis user location: %v", err)
@@ -151,13 +175,46 @@ func (s *UserService) UserLocationPing(ctx context.Context, lat, lon float64) er
 	}
 	// Set TTL on geospatial entry
 	s.redisClient.Expire(ctx, "users:geo", ttl)
+	// Record when this user pinged, the TTL above only covers the whole set
+	err = s.redisClient.ZAdd(ctx, userPingsKey, redis.Z{Score: float64(time.Now().Unix()), Member: user.PublicID}).Err()
+	if err != nil {
+		log.Printf("Failed to record Redis location ping time: %v", err)
+		return err
+	}
+	s.redisClient.Expire(ctx, userPingsKey, ttl)
 
 	log.Printf("Updated location for user %s: lat=%f, lon=%f", user.PublicID, lat, lon)
 	return nil
 }
 
-// GetNearbyUsers retrieves users within a specified radius from a given location
-func (s *UserService) GetNearbyUsers(ctx context.Context, lat, lon float64, radius float64) ([]NearbyUsers, error) {
+// recentLocation returns the user's position in users:geo, ok is false when
+// the user hasn't pinged their location within locationTTL
+func (s *UserService) recentLocation(ctx context.Context, userID string) (lon, lat float64, ok bool, err error) {
+	if s.redisClient == nil {
+		return 0, 0, false, ErrRedisUnavailable
+	}
+	var pos *redis.GeoPosCmd
+	var pinged *redis.FloatCmd
+	_, err = s.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pos = pipe.GeoPos(ctx, "users:geo", userID)
+		pinged = pipe.ZScore(ctx, userPingsKey, userID)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, 0, false, err
+	}
+	positions := pos.Val()
+	if len(positions) == 0 || positions[0] == nil || pinged.Err() != nil {
+		return 0, 0, false, nil
+	}
+	if time.Since(time.Unix(int64(pinged.Val()), 0)) > locationTTL {
+		return 0, 0, false, nil
+	}
+	return positions[0].Longitude, positions[0].Latitude, true, nil
+}
+
+// GetNearbyUsers retrieves users within a specified radius (in meters) from a given location
+func (s *UserService) GetNearbyUsers(ctx context.Context, lat, lon float64, radius float64, units DistanceUnit) ([]NearbyUsers, error) {
 	// Get the userID from the context
 	userID, ok := ctx.Value("userID").(string)
 	if !ok || userID == "" {
@@ -174,9 +231,12 @@ func (s *UserService) GetNearbyUsers(ctx context.Context, lat, lon float64, radi
 	}
 
 	// Get nearby users from Redis geospatial index
+	if s.redisClient == nil {
+		return nil, ErrRedisUnavailable
+	}
 	geoResults, err := s.redisClient.GeoRadius(ctx, "users:geo", lon, lat, &redis.GeoRadiusQuery{
 		Radius:    radius,
-		Unit:      "km",
+		Unit:      "m",
 		WithCoord: true,
 		WithDist:  true,
 	}).Result()
@@ -194,11 +254,11 @@ func (s *UserService) GetNearbyUsers(ctx context.Context, lat, lon float64, radi
 		publicID := geoResult.Name
 		userData, err := s.GetUser(ctx, publicID)
 		user := NearbyUsers{
-			Username: userData.Username,
-			UserID:   userData.PublicID,
-			Lat:      geoResult.Latitude,
-			Lon:      geoResult.Longitude,
-			Distance: geoResult.Dist,
+			Username:  userData.Username,
+			UserID:    userData.PublicID,
+			Lat:       geoResult.Latitude,
+			Lon:       geoResult.Longitude,
+			Direction: newDirection(lon, lat, geoResult.Longitude, geoResult.Latitude, geoResult.Dist, units),
 		}
 		if err != nil {
 			log.Printf("Failed to get user %s: %v", publicID, err)
@@ -210,7 +270,8 @@ func (s *UserService) GetNearbyUsers(ctx context.Context, lat, lon float64, radi
 	return users, nil
 }
 
-func (s *UserService) GetNearbyFriends(ctx context.Context, lat, lon float64, radius float64) ([]NearbyUsers, error) {
+// GetNearbyFriends retrieves friends within a specified radius (in meters) from a given location
+func (s *UserService) GetNearbyFriends(ctx context.Context, lat, lon float64, radius float64, units DistanceUnit) ([]NearbyUsers, error) {
 	// Get the userID from the context
 	userID, ok := ctx.Value("userID").(string)
 	if !ok || userID == "" {
@@ -230,9 +291,12 @@ func (s *UserService) GetNearbyFriends(ctx context.Context, lat, lon float64, ra
 	}
 
 	// Get nearby users from Redis geospatial index
+	if s.redisClient == nil {
+		return nil, ErrRedisUnavailable
+	}
 	geoResults, err := s.redisClient.GeoRadius(ctx, "users:geo", lon, lat, &redis.GeoRadiusQuery{
 		Radius:    radius,
-		Unit:      "km",
+		Unit:      "m",
 		WithCoord: true,
 		WithDist:  true,
 	}).Result()
@@ -257,11 +321,11 @@ func (s *UserService) GetNearbyFriends(ctx context.Context, lat, lon float64, ra
 					continue
 				}
 				nearbyFriend := NearbyUsers{
-					Username: friendData.Username,
-					UserID:   friendData.PublicID,
-					Lat:      geoResult.Latitude,
-					Lon:      geoResult.Longitude,
-					Distance: geoResult.Dist,
+					Username:  friendData.Username,
+					UserID:    friendData.PublicID,
+					Lat:       geoResult.Latitude,
+					Lon:       geoResult.Longitude,
+					Direction: newDirection(lon, lat, geoResult.Longitude, geoResult.Latitude, geoResult.Dist, units),
 				}
 				nearbyFriends = append(nearbyFriends, nearbyFriend)
 			}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore stores files such as POI photos under slash separated keys
+type BlobStore interface {
+	// Put writes a blob, replacing any previous blob with the same key
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Delete removes a blob, deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the address clients fetch a blob from
+	URL(key string) string
+}
@@ -0,0 +1,52 @@
+package taxonomy
+
+import (
+	"go-server/search"
+	"slices"
+)
+
+// A keyword in the name says more about a POI than one in its description
+const (
+	nameMatchScore        = 3
+	descriptionMatchScore = 1
+)
+
+// Classify picks the category whose keywords best match a POI name and
+// description. Each keyword counts once per field, weighted by its number of
+// words so "theme park" beats "park". Ties go to the more specific category
+// and then to the one defined first. It returns "" when no keyword matches.
+func (t *Taxonomy) Classify(name, description string) string {
+	nameTokens := search.Tokenize(name)
+	descriptionTokens := search.Tokenize(description)
+
+	best, bestScore, bestDepth := "", 0, 0
+	for i, id := range t.order {
+		score := 0
+		for _, kw := range t.keywords[i] {
+			if containsPhrase(nameTokens, kw.tokens) {
+				score += nameMatchScore * len(kw.tokens)
+			}
+			if containsPhrase(descriptionTokens, kw.tokens) {
+				score += descriptionMatchScore * len(kw.tokens)
+			}
+		}
+		if score == 0 {
+			continue
+		}
+		depth := t.Depth(id)
+		if score > bestScore || (score == bestScore && depth > bestDepth) {
+			best, bestScore, bestDepth = id, score, depth
+		}
+	}
+	return best
+}
+
+// containsPhrase reports whether phrase occurs as consecutive tokens
+func containsPhrase(tokens, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if slices.Equal(tokens[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+// Package taxonomy holds the POI category tree and the rule based
+// classifier that assigns categories to POIs from their text.
+package taxonomy
+
+import (
+	"fmt"
+	"go-server/models"
+	"go-server/search"
+	"sort"
+	"strings"
+)
+
+// Taxonomy is an immutable category tree
+type Taxonomy struct {
+	categories map[string]models.Category
+	children   map[string][]string
+	order      []string    // Category IDs in definition order
+	keywords   [][]keyword // Keywords per category, aligned with order
+}
+
+// Node is a category with its subcategories, as served by the API
+type Node struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Children []Node `json:"children,omitempty"`
+}
+
+type keyword struct {
+	tokens []string
+}
+
+// New builds a taxonomy, checking that IDs are unique, parents exist and
+// there are no cycles
+func New(categories []models.Category) (*Taxonomy, error) {
+	t := &Taxonomy{
+		categories: make(map[string]models.Category, len(categories)),
+		children:   map[string][]string{},
+	}
+	for _, category := range categories {
+		if category.ID == "" {
+			return nil, fmt.Errorf("category %q has no id", category.Name)
+		}
+		if _, ok := t.categories[category.ID]; ok {
+			return nil, fmt.Errorf("duplicate category %q", category.ID)
+		}
+		t.categories[category.ID] = category
+		t.order = append(t.order, category.ID)
+	}
+	for _, id := range t.order {
+		category := t.categories[id]
+		if category.Parent != "" {
+			if _, ok := t.categories[category.Parent]; !ok {
+				return nil, fmt.Errorf("category %q has unknown parent %q", id, category.Parent)
+			}
+			t.children[category.Parent] = append(t.children[category.Parent], id)
+		}
+		if t.Depth(id) < 0 {
+			return nil, fmt.Errorf("category %q is part of a cycle", id)
+		}
+
+		var keywords []keyword
+		for _, text := range category.Keywords {
+			if tokens := search.Tokenize(text); len(tokens) > 0 {
+				keywords = append(keywords, keyword{tokens: tokens})
+			}
+		}
+		t.keywords = append(t.keywords, keywords)
+	}
+	return t, nil
+}
+
+// Get returns a category by ID
+func (t *Taxonomy) Get(id string) (models.Category, bool) {
+	category, ok := t.categories[id]
+	return category, ok
+}
+
+// Depth returns the number of ancestors of a category, or -1 for a cycle
+func (t *Taxonomy) Depth(id string) int {
+	depth := 0
+	for parent := t.categories[id].Parent; parent != ""; parent = t.categories[parent].Parent {
+		depth++
+		if depth > len(t.categories) {
+			return -1
+		}
+	}
+	return depth
+}
+
+// Descendants returns a category and all categories below it
+func (t *Taxonomy) Descendants(id string) []string {
+	if _, ok := t.categories[id]; !ok {
+		return nil
+	}
+	ids := []string{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, t.children[ids[i]]...)
+	}
+	return ids
+}
+
+// Tree returns the root categories with their subcategories, sorted by name
+func (t *Taxonomy) Tree() []Node {
+	var roots []string
+	for _, id := range t.order {
+		if t.categories[id].Parent == "" {
+			roots = append(roots, id)
+		}
+	}
+	return t.nodes(roots)
+}
+
+func (t *Taxonomy) nodes(ids []string) []Node {
+	nodes := make([]Node, 0, len(ids))
+	for _, id := range ids {
+		category := t.categories[id]
+		nodes = append(nodes, Node{ID: id, Name: category.Name, Children: t.nodes(t.children[id])})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+	return nodes
+}
@@ -33,6 +33,7 @@ func NewAPIError(code, message string, status int, details ...string) *APIError
 var (
 	ErrInvalidInput = NewAPIError("INVALID_INPUT", "Invalid request data", http.StatusBadRequest)
 	ErrUnauthorized = NewAPIError("UNAUTHORIZED", "Authentication required", http.StatusUnauthorized)
+	ErrForbidden    = NewAPIError("FORBIDDEN", "Insufficient permissions", http.StatusForbidden)
 	ErrNotFound     = NewAPIError("NOT_FOUND", "Resource not found", http.StatusNotFound)
 	ErrInternal     = NewAPIError("INTERNAL_SERVER_ERROR", "Internal server error", http.StatusInternalServerError)
 	ErrConflict     = NewAPIError("CONFLICT", "Resource conflict", http.StatusConflict)